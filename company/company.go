@@ -0,0 +1,157 @@
+package company
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// mention matches an @login at the start of a field or after whitespace or
+// punctuation, but not the domain of an email address.
+var mention = regexp.MustCompile(`(?:^|[\s(\[,;/|&+])@([a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)`)
+
+var separators = regexp.MustCompile(`\s*[,;/|]\s*`)
+
+// suffixes are legal forms dropped from the end of company names.
+var suffixes = regexp.MustCompile(`(?i)[\s,]+(?:inc|incorporated|llc|ltd|limited|gmbh|ag|corp|corporation|co|company|plc|s\.?a|b\.?v|s\.?r\.?l|pty)\.?$`)
+
+// Normaliser maps free-form company fields to the GitHub organizations of
+// the employers they name.
+type Normaliser struct {
+	// Aliases maps normalised company names, see Key, to organization logins.
+	Aliases map[string]string
+	// verified holds whether each candidate login is an existing organization,
+	// once Verify has been called.
+	verified map[string]bool
+}
+
+func NewNormaliser(aliases map[string]string) *Normaliser {
+	normalised := map[string]string{}
+	for name, login := range aliases {
+		normalised[Key(name)] = login
+	}
+	return &Normaliser{Aliases: normalised}
+}
+
+// LoadAliases reads an alias table, a JSON object from company names to
+// organization logins, e.g. {"Google LLC": "google"}.
+func LoadAliases(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	aliases := map[string]string{}
+	if err := json.Unmarshal(content, &aliases); err != nil {
+		return nil, fmt.Errorf("error parsing company aliases %s: %w", path, err)
+	}
+	return aliases, nil
+}
+
+// Key normalises a company name for comparison: lowercased, without legal
+// suffixes, a leading @ or surrounding punctuation.
+func Key(name string) string {
+	key := strings.Join(strings.Fields(name), " ")
+	for {
+		stripped := suffixes.ReplaceAllString(key, "")
+		if stripped == key {
+			break
+		}
+		key = stripped
+	}
+	return strings.ToLower(strings.Trim(key, " .,-@()\"'"))
+}
+
+// candidates lists the organization logins a company field may refer to:
+// every @mention, and every aliased name. When guess is set, a plain name
+// without an alias is also tried as a login with its spaces removed.
+func (n *Normaliser) candidates(company string, guess bool) []string {
+	logins := []string{}
+	add := func(login string) {
+		for _, existing := range logins {
+			if strings.EqualFold(existing, login) {
+				return
+			}
+		}
+		logins = append(logins, login)
+	}
+
+	for _, part := range separators.Split(company, -1) {
+		if matches := mention.FindAllStringSubmatch(part, -1); len(matches) > 0 {
+			for _, match := range matches {
+				if alias, ok := n.Aliases[strings.ToLower(match[1])]; ok {
+					add(alias)
+				} else {
+					add(match[1])
+				}
+			}
+			continue
+		}
+
+		key := Key(part)
+		if key == "" {
+			continue
+		}
+		if alias, ok := n.Aliases[key]; ok {
+			add(alias)
+		} else if guess && !strings.Contains(key, "@") {
+			add(strings.ReplaceAll(key, " ", ""))
+		}
+	}
+	return logins
+}
+
+// Organizations returns the organization logins named by a company field.
+// After Verify only existing organizations are returned.
+func (n *Normaliser) Organizations(company string) []string {
+	candidates := n.candidates(company, n.verified != nil)
+	if n.verified == nil {
+		return candidates
+	}
+	logins := []string{}
+	for _, login := range candidates {
+		if n.verified[strings.ToLower(login)] {
+			logins = append(logins, login)
+		}
+	}
+	return logins
+}
+
+// Verify checks every organization login the companies may refer to with
+// exists, costing one API request per distinct login.
+func (n *Normaliser) Verify(ctx context.Context, exists func(ctx context.Context, login string) (bool, error), companies []string) error {
+	if n.verified == nil {
+		n.verified = map[string]bool{}
+	}
+	logins := map[string]string{}
+	for _, company := range companies {
+		for _, login := range n.candidates(company, true) {
+			if _, ok := n.verified[strings.ToLower(login)]; !ok {
+				logins[strings.ToLower(login)] = login
+			}
+		}
+	}
+
+	keys := []string{}
+	for key := range logins {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	log.Printf("verifying %d company organizations", len(keys))
+	for _, key := range keys {
+		ok, err := exists(ctx, logins[key])
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Printf("skipping company organization %s: %v", logins[key], err)
+			continue
+		}
+		n.verified[key] = ok
+	}
+	return nil
+}
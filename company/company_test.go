@@ -0,0 +1,80 @@
+package company
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestOrganizations(t *testing.T) {
+	normaliser := NewNormaliser(map[string]string{"Google LLC": "google", "DeepMind": "google-deepmind"})
+	for _, test := range []struct {
+		company string
+		want    []string
+	}{
+		{"@shopify", []string{"shopify"}},
+		{" @shopify ", []string{"shopify"}},
+		{"me@example.com", []string{}},
+		{"Freelance (me@example.com)", []string{}},
+		{"@google, @deepmind", []string{"google", "google-deepmind"}},
+		{"@microsoft and @github", []string{"microsoft", "github"}},
+		{"@Shopify / Freelance", []string{"Shopify"}},
+		{"Works at (@vercel)", []string{"vercel"}},
+		{"@google @Google", []string{"google"}},
+		{"Google", []string{"google"}},
+		{"Google LLC", []string{"google"}},
+		{"Google, Inc.", []string{"google"}},
+		{"google llc.", []string{"google"}},
+		{"DeepMind Ltd", []string{"google-deepmind"}},
+		{"Acme Corp.", []string{}},
+		{"", []string{}},
+	} {
+		if got := normaliser.Organizations(test.company); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Organizations(%q) = %q, want %q", test.company, got, test.want)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	for name, want := range map[string]string{
+		"Google LLC":       "google",
+		"Google, Inc.":     "google",
+		"SAP SE":           "sap se",
+		"Foo Bar GmbH":     "foo bar",
+		"Acme Corp. Ltd":   "acme",
+		"  Spaced   Name ": "spaced name",
+		"Nestlé S.A.":      "nestlé",
+	} {
+		if got := Key(name); got != want {
+			t.Errorf("Key(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	normaliser := NewNormaliser(map[string]string{"Google LLC": "google"})
+	existing := map[string]bool{"google": true, "shopify": true, "acme": true}
+	checked := []string{}
+	exists := func(ctx context.Context, login string) (bool, error) {
+		checked = append(checked, login)
+		return existing[login], nil
+	}
+
+	companies := []string{"@shopify, @nobody", "Google LLC", "Acme Corp.", "me@example.com"}
+	if err := normaliser.Verify(context.Background(), exists, companies); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"acme", "google", "nobody", "shopify"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("checked %q, want %q", checked, want)
+	}
+	for company, want := range map[string][]string{
+		"@shopify, @nobody": {"shopify"},
+		"Google LLC":        {"google"},
+		"Acme Corp.":        {"acme"},
+		"me@example.com":    {},
+	} {
+		if got := normaliser.Organizations(company); !reflect.DeepEqual(got, want) {
+			t.Errorf("Organizations(%q) = %q, want %q", company, got, want)
+		}
+	}
+}
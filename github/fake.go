@@ -0,0 +1,159 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FakeClient is an in-memory Client serving a programmed set of users,
+// for exercising ranking and output code without the network.
+type FakeClient struct {
+	Users []User
+	// CurrentLogin is the login returned by CurrentUser.
+	CurrentLogin string
+	// Match decides which users a search returns; all users match when nil.
+	Match func(query UserSearchQuery, user User) bool
+	// History holds the contribution history returned per login.
+	History map[string][]YearlyContributions
+	// Orgs are the logins of the organizations that exist.
+	Orgs []string
+	// Err, when set, is returned by every call.
+	Err error
+}
+
+var _ Client = &FakeClient{}
+
+func NewFakeClient(users ...User) *FakeClient {
+	return &FakeClient{Users: users}
+}
+
+func (client *FakeClient) SearchUsers(ctx context.Context, query UserSearchQuery) (GithubSearchResults, error) {
+	if err := client.check(ctx); err != nil {
+		return GithubSearchResults{}, err
+	}
+
+	users := []User{}
+	for _, user := range client.Users {
+		if client.Match == nil || client.Match(query, user) {
+			users = append(users, user)
+		}
+	}
+	total := len(users)
+
+	sort.SliceStable(users, func(i, j int) bool {
+		return users[i].FollowerCount > users[j].FollowerCount
+	})
+	if query.MaxUsers < len(users) {
+		users = users[:query.MaxUsers]
+	}
+
+	return GithubSearchResults{
+		Users:                users,
+		MinimumFollowerCount: MinFollowers(users),
+		TotalUserCount:       total}, nil
+}
+
+// SearchUsersStream yields the results of SearchUsers in pages, resuming
+// after the number of users fetched according to query.Resume.
+func (client *FakeClient) SearchUsersStream(ctx context.Context, query UserSearchQuery) <-chan UserPage {
+	pages := make(chan UserPage)
+	go func() {
+		defer close(pages)
+		send := func(page UserPage) bool {
+			if query.OnProgress != nil && page.Err == nil {
+				query.OnProgress(page.Progress)
+			}
+			select {
+			case pages <- page:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		results, err := client.SearchUsers(ctx, query)
+		if err != nil {
+			send(UserPage{Err: err})
+			return
+		}
+
+		start := 0
+		if query.Resume != nil {
+			start = query.Resume.Fetched
+		}
+		for start < len(results.Users) {
+			end := start + searchPageSize
+			if end > len(results.Users) {
+				end = len(results.Users)
+			}
+			page := UserPage{
+				Users:  results.Users[start:end],
+				Cursor: SearchCursor{Fetched: end, MinFollowerCount: results.Users[end-1].FollowerCount},
+				Progress: SearchProgress{
+					Fetched:    end,
+					MaxUsers:   query.MaxUsers,
+					Query:      query.Q,
+					QueryTotal: results.TotalUserCount}}
+			if !send(page) {
+				return
+			}
+			start = end
+		}
+	}()
+	return pages
+}
+
+func (client *FakeClient) User(ctx context.Context, login string) (User, error) {
+	if err := client.check(ctx); err != nil {
+		return User{}, err
+	}
+	for _, user := range client.Users {
+		if strings.EqualFold(user.Login, login) {
+			return user, nil
+		}
+	}
+	return User{}, fmt.Errorf("user %s not found", login)
+}
+
+func (client *FakeClient) CurrentUser(ctx context.Context) (User, error) {
+	return client.User(ctx, client.CurrentLogin)
+}
+
+func (client *FakeClient) Organizations(ctx context.Context, login string) ([]string, error) {
+	user, err := client.User(ctx, login)
+	if err != nil {
+		return []string{}, err
+	}
+	return user.Organizations, nil
+}
+
+func (client *FakeClient) OrganizationExists(ctx context.Context, login string) (bool, error) {
+	if err := client.check(ctx); err != nil {
+		return false, err
+	}
+	for _, org := range client.Orgs {
+		if strings.EqualFold(org, login) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (client *FakeClient) ContributionHistory(ctx context.Context, login string) ([]YearlyContributions, error) {
+	if _, err := client.User(ctx, login); err != nil {
+		return []YearlyContributions{}, err
+	}
+	if history, ok := client.History[login]; ok {
+		return history, nil
+	}
+	return []YearlyContributions{}, nil
+}
+
+func (client *FakeClient) check(ctx context.Context) error {
+	if client.Err != nil {
+		return client.Err
+	}
+	return ctx.Err()
+}
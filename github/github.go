@@ -1,12 +1,12 @@
 package github
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"math"
 	"net/http"
-	"regexp"
+	"net/url"
 	"strings"
 	"time"
 
@@ -14,30 +14,51 @@ import (
 )
 
 const root string = "https://api.github.com/"
+const graphQLURL string = root + "graphql"
+
+type Client interface {
+	SearchUsers(ctx context.Context, query UserSearchQuery) (GithubSearchResults, error)
+	SearchUsersStream(ctx context.Context, query UserSearchQuery) <-chan UserPage
+	User(ctx context.Context, login string) (User, error)
+	CurrentUser(ctx context.Context) (User, error)
+	Organizations(ctx context.Context, login string) ([]string, error)
+	OrganizationExists(ctx context.Context, login string) (bool, error)
+	ContributionHistory(ctx context.Context, login string) ([]YearlyContributions, error)
+}
+
+var _ Client = HTTPGithubClient{}
 
 type HTTPGithubClient struct {
 	wrappers []net.Wrapper
 }
 
-func (client HTTPGithubClient) Request(url string, body string) ([]byte, error) {
+func (client HTTPGithubClient) Request(ctx context.Context, url string, body string) ([]byte, error) {
+	return client.request(ctx, url, body, client.wrappers)
+}
+
+func (client HTTPGithubClient) request(ctx context.Context, url string, body string, wrappers []net.Wrapper) ([]byte, error) {
 	httpClient := &http.Client{}
 	var req *http.Request
 	var err error
 	if body != "" {
-		req, err = http.NewRequest("POST", url, strings.NewReader(body))
+		req, err = http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(body))
 	} else {
-		req, err = http.NewRequest("GET", url, nil)
+		req, err = http.NewRequestWithContext(ctx, "GET", url, nil)
 	}
 
 	if err != nil {
 		return []byte{}, err
 	}
 
-	return net.Compose(client.wrappers...)(net.MakeRequester(httpClient))(req)
+	resp, err := net.Compose(wrappers...)(net.MakeRequester(httpClient))(ctx, req)
+	if err != nil {
+		return []byte{}, err
+	}
+	return resp.Body, nil
 }
 
-func (client HTTPGithubClient) CurrentUser() (User, error) {
-	body, err := client.Request(fmt.Sprintf("%suser", root), "")
+func (client HTTPGithubClient) CurrentUser(ctx context.Context) (User, error) {
+	body, err := client.Request(ctx, fmt.Sprintf("%suser", root), "")
 	if err != nil {
 		return User{}, err
 	}
@@ -49,8 +70,8 @@ func (client HTTPGithubClient) CurrentUser() (User, error) {
 	return user, nil
 }
 
-func (client HTTPGithubClient) User(login string) (User, error) {
-	body, err := client.Request(fmt.Sprintf("%susers/%s", root, login), "")
+func (client HTTPGithubClient) User(ctx context.Context, login string) (User, error) {
+	body, err := client.Request(ctx, fmt.Sprintf("%susers/%s", root, login), "")
 	if err != nil {
 		return User{}, err
 	}
@@ -62,181 +83,6 @@ func (client HTTPGithubClient) User(login string) (User, error) {
 	return user, nil
 }
 
-func (client HTTPGithubClient) SearchUsers(query UserSearchQuery) (GithubSearchResults, error) {
-	users := []User{}
-	userLogins := map[string]bool{}
-
-	totalCount := 0
-	minFollowerCount := -1
-	maxPerQuery := 1000
-	perPage := 5
-	totalUsersCount := 0
-
-	retryCount := 0
-	maxRetryCount := 10
-
-Pages:
-	for totalCount < query.MaxUsers {
-		previousCursor := ""
-		followerCountQueryStr := ""
-		if minFollowerCount >= 0 {
-			followerCountQueryStr = fmt.Sprintf(" followers:<%d", minFollowerCount)
-		}
-		for currentPage := 1; currentPage <= (maxPerQuery / perPage); currentPage++ {
-			cursorQueryStr := ""
-			if previousCursor != "" {
-				cursorQueryStr = fmt.Sprintf(", after: \\\"%s\\\"", previousCursor)
-			}
-			graphQlString := fmt.Sprintf(`{ "query": "query {
-        search(type: USER, query:\"%s%s sort:%s-%s\", first: %d%s) {
-          userCount
-          edges {
-            node {
-              __typename
-              ... on User {
-                login,
-                avatarUrl,
-                name,
-                company,
-                organizations(first: 100) {
-                  nodes {
-                    login
-                  }
-                }
-                followers {
-                  totalCount
-                }
-                contributionsCollection {
-                  contributionCalendar {
-                    totalContributions
-                  },
-                  totalCommitContributions,
-                  totalPullRequestContributions,
-                  restrictedContributionsCount
-                }
-              }
-            },
-            cursor
-          }
-        }
-      }" }`, query.Q, followerCountQueryStr, query.Sort, query.Order, perPage, cursorQueryStr)
-
-			re := regexp.MustCompile(`\r?\n`)
-			graphQlString = re.ReplaceAllString(graphQlString, " ")
-
-			body, err := client.Request("https://api.github.com/graphql", graphQlString)
-			if err != nil {
-				retryCount++
-				if retryCount < maxRetryCount {
-					log.Println("error making graphql request... retrying")
-					time.Sleep(10 * time.Second)
-					continue Pages
-				} else {
-					log.Fatalln("Too many errors received. Quitting.")
-				}
-			}
-
-			var response interface{}
-			if err := json.Unmarshal(body, &response); err != nil {
-				retryCount++
-				if retryCount < maxRetryCount {
-					log.Println("error unmarshalling JSON response... retrying")
-					time.Sleep(10 * time.Second)
-					continue Pages
-				} else {
-					log.Fatalln("Too many errors received. Quitting.")
-				}
-			}
-			rootNode := response.(map[string]interface{})
-			if val, ok := rootNode["errors"]; ok {
-				retryCount++
-				if retryCount < maxRetryCount {
-					log.Printf("Received error response (retrying): %+v", val)
-					time.Sleep(10 * time.Second)
-					continue Pages
-				} else {
-					log.Fatalln("Too many errors received. Quitting.")
-				}
-			}
-			dataNode, ok := rootNode["data"].(map[string]interface{})
-			if !ok {
-				retryCount++
-				if retryCount < maxRetryCount {
-					log.Println("Error accessing data element")
-					time.Sleep(10 * time.Second)
-					continue Pages
-				} else {
-					log.Fatalln("Too many errors received. Quitting.")
-				}
-			}
-
-			searchNode := dataNode["search"].(map[string]interface{})
-			totalUsersCount = int(searchNode["userCount"].(float64))
-			edgeNodes := searchNode["edges"].([]interface{})
-
-			if len(edgeNodes) == 0 {
-				break Pages
-			}
-			totalCount += len(edgeNodes)
-
-		Edges:
-			for _, edge := range edgeNodes {
-				edgeNode := edge.(map[string]interface{})
-				userNode := edgeNode["node"].(map[string]interface{})
-				typename := userNode["__typename"].(string)
-				if typename != "User" {
-					continue Edges
-				}
-				login := userNode["login"].(string)
-				avatarURL := userNode["avatarUrl"].(string)
-				name := strPropOrEmpty(userNode, "name")
-				company := strPropOrEmpty(userNode, "company")
-				organizations := []string{}
-
-				orgNodes := userNode["organizations"].(map[string]interface{})["nodes"].([]interface{})
-				for _, orgNode := range orgNodes {
-
-					organizations = append(organizations, orgNode.(map[string]interface{})["login"].(string))
-				}
-
-				followerCount := int(userNode["followers"].(map[string]interface{})["totalCount"].(float64))
-				contributionsCollection := userNode["contributionsCollection"].(map[string]interface{})
-				totalContributionCount := int(contributionsCollection["contributionCalendar"].(map[string]interface{})["totalContributions"].(float64))
-				privateContributionCount := int(contributionsCollection["restrictedContributionsCount"].(float64))
-				publicContributionCount := totalContributionCount - privateContributionCount
-				commitsCount := int(contributionsCollection["totalCommitContributions"].(float64))
-				pullRequestsCount := int(contributionsCollection["totalPullRequestContributions"].(float64))
-
-				user := User{
-					Login:                    login,
-					AvatarURL:                avatarURL,
-					Name:                     name,
-					Company:                  company,
-					Organizations:            organizations,
-					FollowerCount:            followerCount,
-					ContributionCount:        totalContributionCount,
-					PublicContributionCount:  publicContributionCount,
-					PrivateContributionCount: privateContributionCount,
-					CommitsCount:             commitsCount,
-					PullRequestsCount:        pullRequestsCount}
-
-				if !userLogins[login] {
-					userLogins[login] = true
-					users = append(users, user)
-				}
-
-				previousCursor = edgeNode["cursor"].(string)
-				minFollowerCount = int(followerCount)
-			}
-		}
-	}
-
-	return GithubSearchResults{
-		Users:                users,
-		MinimumFollowerCount: minFollowerCount,
-		TotalUserCount:       totalUsersCount}, nil
-}
-
 func MinFollowers(users []User) int {
 	if len(users) == 0 {
 		return 0
@@ -250,28 +96,16 @@ func MinFollowers(users []User) int {
 	return min
 }
 
-func strPropOrEmpty(obj map[string]interface{}, prop string) string {
-	switch t := obj[prop].(type) {
-	case string:
-		return t
-	default:
-		return ""
-	}
-
-}
-
-func (client HTTPGithubClient) Organizations(login string) ([]string, error) {
+func (client HTTPGithubClient) Organizations(ctx context.Context, login string) ([]string, error) {
 	url := fmt.Sprintf("https://api.github.com/users/%s/orgs", login)
-	body, err := client.Request(url, "")
+	body, err := client.Request(ctx, url, "")
 	if err != nil {
-		log.Fatalf("error requesting organizations for user %+v", login)
-		return []string{}, err
+		return []string{}, fmt.Errorf("error requesting organizations for user %+v: %w", login, err)
 	}
 	orgResp := []OrgResponse{}
 	err = json.Unmarshal(body, &orgResp)
 	if err != nil {
-		log.Fatalf("error parsing organizations JSON for user %+v", login)
-		return []string{}, err
+		return []string{}, fmt.Errorf("error parsing organizations JSON for user %+v: %w", login, err)
 	}
 	orgs := []string{}
 
@@ -282,6 +116,17 @@ func (client HTTPGithubClient) Organizations(login string) ([]string, error) {
 	return orgs, err
 }
 
+func (client HTTPGithubClient) OrganizationExists(ctx context.Context, login string) (bool, error) {
+	_, err := client.Request(ctx, fmt.Sprintf("%sorgs/%s", root, url.PathEscape(login)), "")
+	if net.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error requesting organization %+v: %w", login, err)
+	}
+	return true, nil
+}
+
 type OrgResponse struct {
 	Organization string `json:"login"`
 }
@@ -302,13 +147,35 @@ type User struct {
 	PrivateContributionCount int
 	CommitsCount             int
 	PullRequestsCount        int
+	IssuesCount              int
+	PullRequestReviewsCount  int
+	RepositoriesCreatedCount int
+	// the number of distinct repositories contributed to, by contribution type
+	CommitRepositoriesCount      int
+	IssueRepositoriesCount       int
+	PullRequestRepositoriesCount int
+	ReviewRepositoriesCount      int
+	HasRestrictedContributions   bool
+	// History is only filled in when requested, see Client.ContributionHistory.
+	History []YearlyContributions `json:",omitempty"`
 }
 
 type UserSearchQuery struct {
-	Q        string
-	Sort     string
-	Order    string
-	MaxUsers int
+	Q         string
+	Sort      string
+	Order     string
+	MaxUsers  int
+	Partition PartitionStrategy
+	// From and To limit the contributions counted for each user; GitHub's
+	// default of the last year applies when they are zero.
+	From time.Time
+	To   time.Time
+	// Resume continues a search from a cursor yielded by SearchUsersStream,
+	// skipping the users in SeenLogins that were yielded before it.
+	Resume     *SearchCursor
+	SeenLogins map[string]bool
+	// OnProgress is called after every page.
+	OnProgress func(SearchProgress)
 }
 
 type GithubSearchResults struct {
@@ -0,0 +1,188 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"most-active-github-users-counter/net"
+)
+
+const searchUsersQuery = `query SearchUsers($q: String!, $first: Int!, $after: String, $from: DateTime, $to: DateTime) {
+  search(type: USER, query: $q, first: $first, after: $after) {
+    userCount
+    edges {
+      node {
+        __typename
+        ... on User {
+          login
+          avatarUrl
+          name
+          company
+          organizations(first: 100) {
+            nodes {
+              login
+            }
+          }
+          followers {
+            totalCount
+          }
+          contributionsCollection(from: $from, to: $to) {
+            ...contributions
+          }
+        }
+      }
+      cursor
+    }
+  }
+}
+` + contributionsFragment
+
+// contributionsFragment selects the fields decoded into contributionsNode.
+const contributionsFragment = `fragment contributions on ContributionsCollection {
+  contributionCalendar {
+    totalContributions
+  }
+  totalCommitContributions
+  totalPullRequestContributions
+  totalIssueContributions
+  totalPullRequestReviewContributions
+  totalRepositoryContributions
+  totalRepositoriesWithContributedCommits
+  totalRepositoriesWithContributedIssues
+  totalRepositoriesWithContributedPullRequests
+  totalRepositoriesWithContributedPullRequestReviews
+  restrictedContributionsCount
+  hasAnyRestrictedContributions
+}`
+
+const contributionYearsQuery = `query ContributionYears($login: String!) {
+  user(login: $login) {
+    contributionsCollection {
+      contributionYears
+    }
+  }
+}`
+
+const searchUserCountQuery = `query CountUsers($q: String!) {
+  search(type: USER, query: $q, first: 1) {
+    userCount
+  }
+}`
+
+// GraphQLRequest is the JSON body of a GraphQL call. Values that come from
+// user input belong in Variables rather than in the query text.
+type GraphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// GraphQL sends a GraphQL query and fails with a GraphQLError if the response
+// reports errors, so that wrappers such as net.Retry can act on them.
+func (client HTTPGithubClient) GraphQL(ctx context.Context, request GraphQLRequest) ([]byte, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return []byte{}, err
+	}
+	return client.request(ctx, graphQLURL, string(body), append([]net.Wrapper{checkGraphQLResponse}, client.wrappers...))
+}
+
+// invalidQuery is the type given to errors that point into the query text,
+// such as parse errors, which GitHub reports without a type.
+const invalidQuery = "INVALID_QUERY"
+
+type GraphQLError struct {
+	Messages []string
+	// Types holds the type of each error, e.g. RATE_LIMITED or NOT_FOUND, or
+	// the code of a query validation error. It is empty for unexpected
+	// failures such as timeouts.
+	Types []string
+}
+
+func (e *GraphQLError) Error() string {
+	return fmt.Sprintf("graphql error: %s", strings.Join(e.Messages, "; "))
+}
+
+// RateLimited reports whether GitHub rejected the query because of the rate
+// limit, which GraphQL reports with a 200 status.
+func (e *GraphQLError) RateLimited() bool {
+	for _, t := range e.Types {
+		if t == "RATE_LIMITED" {
+			return true
+		}
+	}
+	return false
+}
+
+// Retryable reports whether sending the query again may succeed, which is
+// not the case for errors in the query or its variables.
+func (e *GraphQLError) Retryable() bool {
+	if e.RateLimited() {
+		return true
+	}
+	for _, t := range e.Types {
+		if t != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func checkGraphQLResponse(r net.Requester) net.Requester {
+	return func(ctx context.Context, req *http.Request) (net.Response, error) {
+		resp, err := r(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+
+		var envelope struct {
+			Data   json.RawMessage
+			Errors []struct {
+				Message    string
+				Type       string
+				Path       []interface{}
+				Locations  []interface{}
+				Extensions struct {
+					Code string
+				}
+			}
+		}
+		if err := json.Unmarshal(resp.Body, &envelope); err != nil {
+			return resp, fmt.Errorf("error unmarshalling JSON response: %w", err)
+		}
+		hasData := len(envelope.Data) > 0 && string(envelope.Data) != "null"
+		if !hasData && len(envelope.Errors) == 0 {
+			return resp, errors.New("graphql response has no data element")
+		}
+
+		// errors with a path only affect individual nodes, which are skipped
+		// when decoding, as long as the rest of the data came through
+		graphQLErr := &GraphQLError{}
+		partial := hasData
+		for _, e := range envelope.Errors {
+			errorType := e.Type
+			if errorType == "" {
+				errorType = e.Extensions.Code
+			}
+			if errorType == "" && len(e.Locations) > 0 && len(e.Path) == 0 {
+				errorType = invalidQuery
+			}
+			graphQLErr.Messages = append(graphQLErr.Messages, e.Message)
+			graphQLErr.Types = append(graphQLErr.Types, errorType)
+			partial = partial && len(e.Path) > 0
+		}
+		// the response is kept so that outer wrappers still see its headers,
+		// e.g. the exhausted budget of a rate limited query
+		if len(graphQLErr.Messages) > 0 && !partial {
+			return resp, graphQLErr
+		}
+		for _, e := range envelope.Errors {
+			log.Printf("ignoring graphql error at %v: %s", e.Path, e.Message)
+		}
+		return resp, nil
+	}
+}
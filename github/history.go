@@ -0,0 +1,104 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type YearlyContributions struct {
+	Year                     int
+	ContributionCount        int
+	PublicContributionCount  int
+	PrivateContributionCount int
+	CommitsCount             int
+	PullRequestsCount        int
+	IssuesCount              int
+	PullRequestReviewsCount  int
+}
+
+// ContributionHistory fetches a user's contribution totals for every year
+// they have been active, oldest first. The years are looked up first and
+// then fetched together as one aliased contributionsCollection per year.
+func (client HTTPGithubClient) ContributionHistory(ctx context.Context, login string) ([]YearlyContributions, error) {
+	years, err := client.contributionYears(ctx, login)
+	if err != nil || len(years) == 0 {
+		return []YearlyContributions{}, err
+	}
+
+	variables := map[string]interface{}{"login": login}
+	params := []string{"$login: String!"}
+	fields := []string{}
+	for _, year := range years {
+		from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		variables[fmt.Sprintf("from%d", year)] = from.Format(time.RFC3339)
+		variables[fmt.Sprintf("to%d", year)] = from.AddDate(1, 0, 0).Add(-time.Second).Format(time.RFC3339)
+		params = append(params, fmt.Sprintf("$from%d: DateTime!, $to%d: DateTime!", year, year))
+		fields = append(fields, fmt.Sprintf("    y%d: contributionsCollection(from: $from%d, to: $to%d) {\n      ...contributions\n    }", year, year, year))
+	}
+	query := fmt.Sprintf("query ContributionHistory(%s) {\n  user(login: $login) {\n%s\n  }\n}\n%s",
+		strings.Join(params, ", "), strings.Join(fields, "\n"), contributionsFragment)
+
+	body, err := client.GraphQL(ctx, GraphQLRequest{Query: query, Variables: variables})
+	if err != nil {
+		return []YearlyContributions{}, fmt.Errorf("error requesting contribution history for user %s: %w", login, err)
+	}
+
+	var response struct {
+		Data struct {
+			User map[string]*contributionsNode
+		}
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return []YearlyContributions{}, fmt.Errorf("error parsing contribution history for user %s: %w", login, err)
+	}
+
+	history := []YearlyContributions{}
+	for _, year := range years {
+		node := response.Data.User[fmt.Sprintf("y%d", year)]
+		if node == nil {
+			continue
+		}
+		total := node.ContributionCalendar.TotalContributions
+		history = append(history, YearlyContributions{
+			Year:                     year,
+			ContributionCount:        total,
+			PublicContributionCount:  total - node.RestrictedContributionsCount,
+			PrivateContributionCount: node.RestrictedContributionsCount,
+			CommitsCount:             node.TotalCommitContributions,
+			PullRequestsCount:        node.TotalPullRequestContributions,
+			IssuesCount:              node.TotalIssueContributions,
+			PullRequestReviewsCount:  node.TotalPullRequestReviewContributions})
+	}
+	return history, nil
+}
+
+func (client HTTPGithubClient) contributionYears(ctx context.Context, login string) ([]int, error) {
+	body, err := client.GraphQL(ctx, GraphQLRequest{Query: contributionYearsQuery, Variables: map[string]interface{}{"login": login}})
+	if err != nil {
+		return nil, fmt.Errorf("error requesting contribution years for user %s: %w", login, err)
+	}
+
+	var response struct {
+		Data struct {
+			User *struct {
+				ContributionsCollection struct {
+					ContributionYears []int
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error parsing contribution years for user %s: %w", login, err)
+	}
+	if response.Data.User == nil {
+		return nil, fmt.Errorf("user %s not found", login)
+	}
+
+	years := response.Data.User.ContributionsCollection.ContributionYears
+	sort.Ints(years)
+	return years, nil
+}
@@ -0,0 +1,115 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+type searchResponse struct {
+	Data struct {
+		Search struct {
+			UserCount int
+			Edges     []searchEdge
+		}
+	}
+}
+
+type searchEdge struct {
+	Cursor string
+	// Node is decoded separately so that one malformed user doesn't fail the whole page.
+	Node json.RawMessage
+}
+
+type searchUserNode struct {
+	Typename      string `json:"__typename"`
+	Login         string
+	AvatarURL     string
+	Name          string
+	Company       string
+	Organizations *struct {
+		Nodes []*struct {
+			Login string
+		}
+	}
+	Followers *struct {
+		TotalCount int
+	}
+	ContributionsCollection *contributionsNode
+}
+
+type contributionsNode struct {
+	ContributionCalendar struct {
+		TotalContributions int
+	}
+	TotalCommitContributions                           int
+	TotalPullRequestContributions                      int
+	TotalIssueContributions                            int
+	TotalPullRequestReviewContributions                int
+	TotalRepositoryContributions                       int
+	TotalRepositoriesWithContributedCommits            int
+	TotalRepositoriesWithContributedIssues             int
+	TotalRepositoriesWithContributedPullRequests       int
+	TotalRepositoriesWithContributedPullRequestReviews int
+	RestrictedContributionsCount                       int
+	HasAnyRestrictedContributions                      bool
+}
+
+// decodeSearchNode decodes a search result node. Nodes that aren't users are
+// reported with ok set to false and no error.
+func decodeSearchNode(raw json.RawMessage) (user User, ok bool, err error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return User{}, false, errors.New("node is null")
+	}
+
+	node := searchUserNode{}
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return User{}, false, err
+	}
+	if node.Typename != "User" {
+		return User{}, false, nil
+	}
+	if node.Login == "" {
+		return User{}, false, errors.New("user has no login")
+	}
+	if node.Followers == nil {
+		return User{}, false, fmt.Errorf("user %s has no followers", node.Login)
+	}
+	if node.ContributionsCollection == nil {
+		return User{}, false, fmt.Errorf("user %s has no contributionsCollection", node.Login)
+	}
+
+	organizations := []string{}
+	if node.Organizations != nil {
+		for _, org := range node.Organizations.Nodes {
+			if org != nil && org.Login != "" {
+				organizations = append(organizations, org.Login)
+			}
+		}
+	}
+
+	contributions := node.ContributionsCollection
+	totalContributionCount := contributions.ContributionCalendar.TotalContributions
+	privateContributionCount := contributions.RestrictedContributionsCount
+
+	return User{
+		Login:                        node.Login,
+		AvatarURL:                    node.AvatarURL,
+		Name:                         node.Name,
+		Company:                      node.Company,
+		Organizations:                organizations,
+		FollowerCount:                node.Followers.TotalCount,
+		ContributionCount:            totalContributionCount,
+		PublicContributionCount:      totalContributionCount - privateContributionCount,
+		PrivateContributionCount:     privateContributionCount,
+		CommitsCount:                 contributions.TotalCommitContributions,
+		PullRequestsCount:            contributions.TotalPullRequestContributions,
+		IssuesCount:                  contributions.TotalIssueContributions,
+		PullRequestReviewsCount:      contributions.TotalPullRequestReviewContributions,
+		RepositoriesCreatedCount:     contributions.TotalRepositoryContributions,
+		CommitRepositoriesCount:      contributions.TotalRepositoriesWithContributedCommits,
+		IssueRepositoriesCount:       contributions.TotalRepositoriesWithContributedIssues,
+		PullRequestRepositoriesCount: contributions.TotalRepositoriesWithContributedPullRequests,
+		ReviewRepositoriesCount:      contributions.TotalRepositoriesWithContributedPullRequestReviews,
+		HasRestrictedContributions:   contributions.HasAnyRestrictedContributions}, true, nil
+}
@@ -0,0 +1,383 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"most-active-github-users-counter/net"
+)
+
+// searchResultCap is the maximum number of results GitHub returns for a single search query.
+const searchResultCap = 1000
+
+const searchPageSize = 5
+
+// PartitionStrategy decides how a search is split into queries that each
+// stay below the search result cap.
+type PartitionStrategy string
+
+const (
+	// PartitionByFollowers pages through users sorted by followers and, once
+	// a query hits the cap, continues below the lowest follower count seen.
+	PartitionByFollowers PartitionStrategy = "followers"
+	// PartitionByCreated splits the search into account creation date ranges,
+	// and days that are still too large into follower bands, so that every
+	// matching user can be reached. The ranges are paged oldest first, so it
+	// is meant for enumerating every user: with a MaxUsers below the total,
+	// the users kept are the oldest accounts rather than the most followed.
+	PartitionByCreated PartitionStrategy = "created"
+)
+
+// firstAccountCreated is a lower bound for the creation date of any GitHub account.
+var firstAccountCreated = time.Date(2007, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+// lastAccountCreated is a fixed upper bound for account creation dates. Unlike
+// today's date it keeps the partition queries, and any responses recorded for
+// them, the same from one day to the next, at the cost of a few count queries
+// for empty ranges in the future.
+var lastAccountCreated = time.Date(2099, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// SearchStep is a query of a partitioned search that still has to be run.
+type SearchStep struct {
+	Kind       string `json:"kind"`
+	Qualifiers string `json:"qualifiers,omitempty"`
+}
+
+const (
+	// stepFollowers pages a query and continues below the lowest follower
+	// count seen if it hits the cap.
+	stepFollowers = "followers"
+	// stepBoundary pages the users sharing one follower count and splits
+	// them by creation date if they hit the cap.
+	stepBoundary = "boundary"
+	// stepSplit and stepSplitBoundary replace themselves with partitions,
+	// only the former may fall back to follower bands.
+	stepSplit         = "split"
+	stepSplitBoundary = "split-boundary"
+	stepPartition     = "partition"
+)
+
+// SearchCursor is the position of a search after a page, from which it can
+// be resumed through UserSearchQuery.Resume.
+type SearchCursor struct {
+	Steps            []SearchStep `json:"steps"`
+	After            string       `json:"after,omitempty"`
+	Page             int          `json:"page"`
+	MinFollowerCount int          `json:"minFollowerCount"`
+	Fetched          int          `json:"fetched"`
+}
+
+func initialCursor(strategy PartitionStrategy) (SearchCursor, error) {
+	switch strategy {
+	case PartitionByCreated:
+		return SearchCursor{Steps: []SearchStep{{Kind: stepSplit}}, MinFollowerCount: -1}, nil
+	case PartitionByFollowers, "":
+		return SearchCursor{Steps: []SearchStep{{Kind: stepFollowers}}, MinFollowerCount: -1}, nil
+	}
+	return SearchCursor{}, fmt.Errorf("unknown partition strategy %q", strategy)
+}
+
+func (cursor SearchCursor) clone() SearchCursor {
+	cursor.Steps = append([]SearchStep{}, cursor.Steps...)
+	return cursor
+}
+
+// finishStep moves on from the current step, queueing next steps first.
+func (cursor *SearchCursor) finishStep(next ...SearchStep) {
+	cursor.Steps = append(next, cursor.Steps[1:]...)
+	cursor.After = ""
+	cursor.Page = 0
+}
+
+type SearchProgress struct {
+	// Fetched is the number of distinct users found so far.
+	Fetched  int
+	MaxUsers int
+	// Query is the search query being paged and QueryTotal its match count.
+	Query      string
+	QueryTotal int
+}
+
+// UserPage is one page of a streamed search. The last page of a failed
+// search only carries Err.
+type UserPage struct {
+	Users    []User
+	Cursor   SearchCursor
+	Progress SearchProgress
+	Err      error
+}
+
+type userSearch struct {
+	client     HTTPGithubClient
+	query      UserSearchQuery
+	cursor     SearchCursor
+	userLogins map[string]bool
+}
+
+func (client HTTPGithubClient) SearchUsers(ctx context.Context, query UserSearchQuery) (GithubSearchResults, error) {
+	return CollectUsers(ctx, client.SearchUsersStream(ctx, query))
+}
+
+// SearchUsersStream runs a search in the background and yields its users
+// page by page. The channel is closed when the search is complete or has
+// failed; cancel ctx to stop early.
+func (client HTTPGithubClient) SearchUsersStream(ctx context.Context, query UserSearchQuery) <-chan UserPage {
+	pages := make(chan UserPage)
+	go func() {
+		defer close(pages)
+		search := &userSearch{client: client, query: query, userLogins: map[string]bool{}}
+		for login := range query.SeenLogins {
+			search.userLogins[login] = true
+		}
+		err := search.run(ctx, func(page UserPage) bool {
+			if query.OnProgress != nil {
+				query.OnProgress(page.Progress)
+			}
+			select {
+			case pages <- page:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if net.IsUnauthorized(err) {
+			err = fmt.Errorf("invalid or expired GitHub token: %w", err)
+		}
+		if err != nil {
+			select {
+			case pages <- UserPage{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return pages
+}
+
+// CollectUsers gathers a streamed search into a single result. On errors,
+// it hands back what was collected so far, e.g. when the run was cancelled.
+func CollectUsers(ctx context.Context, pages <-chan UserPage) (GithubSearchResults, error) {
+	results := GithubSearchResults{Users: []User{}, MinimumFollowerCount: -1}
+	for page := range pages {
+		if page.Err != nil {
+			return results, page.Err
+		}
+		results.Users = append(results.Users, page.Users...)
+		results.MinimumFollowerCount = page.Cursor.MinFollowerCount
+		results.TotalUserCount = page.Progress.QueryTotal
+	}
+	// a cancelled stream closes without reporting an error
+	return results, ctx.Err()
+}
+
+func (search *userSearch) done() bool {
+	return search.cursor.Fetched >= search.query.MaxUsers
+}
+
+func (search *userSearch) run(ctx context.Context, emit func(UserPage) bool) error {
+	// a cursor saved before the first page came through has no steps yet,
+	// and resuming from it starts the search over
+	if resume := search.query.Resume; resume != nil && (len(resume.Steps) > 0 || resume.Fetched > 0) {
+		search.cursor = resume.clone()
+	} else {
+		cursor, err := initialCursor(search.query.Partition)
+		if err != nil {
+			return err
+		}
+		search.cursor = cursor
+	}
+
+	for len(search.cursor.Steps) > 0 && !search.done() {
+		step := search.cursor.Steps[0]
+		switch step.Kind {
+		case stepSplit, stepSplitBoundary:
+			partitions := []string{}
+			total, err := search.splitCreated(ctx, step.Qualifiers, firstAccountCreated, lastAccountCreated, step.Kind == stepSplit, &partitions)
+			if err != nil {
+				return err
+			}
+			if step.Kind == stepSplit && total > search.query.MaxUsers {
+				log.Printf("%d users match, but only %d are considered: partitions by creation date are fetched oldest account first, so they won't be the most followed", total, search.query.MaxUsers)
+			}
+			next := []SearchStep{}
+			for _, qualifiers := range partitions {
+				next = append(next, SearchStep{Kind: stepPartition, Qualifiers: qualifiers})
+			}
+			search.cursor.finishStep(next...)
+		case stepFollowers, stepBoundary, stepPartition:
+			page, err := search.page(ctx, step)
+			if err != nil {
+				return err
+			}
+			if !emit(page) {
+				return ctx.Err()
+			}
+		default:
+			return fmt.Errorf("unknown search step %q", step.Kind)
+		}
+	}
+	return nil
+}
+
+// page fetches the next page of step and advances the cursor past it.
+func (search *userSearch) page(ctx context.Context, step SearchStep) (UserPage, error) {
+	cursor := &search.cursor
+	queryString := search.queryString(step.Qualifiers)
+	variables := map[string]interface{}{
+		"q":     queryString,
+		"first": searchPageSize,
+	}
+	if cursor.After != "" {
+		variables["after"] = cursor.After
+	}
+	if !search.query.From.IsZero() {
+		variables["from"] = search.query.From.Format(time.RFC3339)
+	}
+	if !search.query.To.IsZero() {
+		variables["to"] = search.query.To.Format(time.RFC3339)
+	}
+
+	body, err := search.client.GraphQL(ctx, GraphQLRequest{Query: searchUsersQuery, Variables: variables})
+	if err != nil {
+		return UserPage{}, fmt.Errorf("error searching users: %w", err)
+	}
+
+	response := searchResponse{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return UserPage{}, fmt.Errorf("error unmarshalling search response: %w", err)
+	}
+
+	result := response.Data.Search
+	users := []User{}
+	for _, edge := range result.Edges {
+		cursor.After = edge.Cursor
+
+		user, ok, err := decodeSearchNode(edge.Node)
+		if err != nil {
+			log.Printf("skipping malformed search result: %v", err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		if !search.userLogins[user.Login] {
+			search.userLogins[user.Login] = true
+			users = append(users, user)
+		}
+		cursor.MinFollowerCount = user.FollowerCount
+	}
+	cursor.Fetched += len(users)
+	cursor.Page++
+
+	if len(result.Edges) < searchPageSize {
+		cursor.finishStep()
+	} else if cursor.Page >= searchResultCap/searchPageSize {
+		// the query hit the cap, so it has to be continued another way
+		switch step.Kind {
+		case stepFollowers:
+			if cursor.MinFollowerCount < 0 {
+				return UserPage{}, errors.New("search results carry no follower counts to continue from")
+			}
+			// the cap may have cut off users sharing the lowest follower
+			// count seen, so fetch all of them before moving below it
+			cursor.finishStep(
+				SearchStep{Kind: stepBoundary, Qualifiers: fmt.Sprintf(" followers:%d", cursor.MinFollowerCount)},
+				SearchStep{Kind: stepFollowers, Qualifiers: fmt.Sprintf(" followers:<%d", cursor.MinFollowerCount)})
+		case stepBoundary:
+			cursor.finishStep(SearchStep{Kind: stepSplitBoundary, Qualifiers: step.Qualifiers})
+		default:
+			cursor.finishStep()
+		}
+	}
+
+	return UserPage{
+		Users:  users,
+		Cursor: cursor.clone(),
+		Progress: SearchProgress{
+			Fetched:    cursor.Fetched,
+			MaxUsers:   search.query.MaxUsers,
+			Query:      queryString,
+			QueryTotal: result.UserCount}}, nil
+}
+
+// splitCreated bisects the creation date range until each part fits under
+// the result cap, falling back to follower bands for single days if allowed.
+// It returns the number of users created in the range.
+func (search *userSearch) splitCreated(ctx context.Context, base string, from time.Time, to time.Time, splitFollowers bool, partitions *[]string) (int, error) {
+	qualifiers := fmt.Sprintf("%s created:%s..%s", base, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	count, err := search.count(ctx, qualifiers)
+	if err != nil || count == 0 {
+		return count, err
+	}
+	if count <= searchResultCap {
+		*partitions = append(*partitions, qualifiers)
+		return count, nil
+	}
+	if !from.Before(to) {
+		if splitFollowers {
+			return count, search.splitFollowers(ctx, qualifiers, 0, -1, partitions)
+		}
+		log.Printf("%d users match%s, only the first %d can be fetched", count, qualifiers, searchResultCap)
+		*partitions = append(*partitions, qualifiers)
+		return count, nil
+	}
+
+	days := int(to.Sub(from).Hours() / 24)
+	mid := from.AddDate(0, 0, days/2)
+	if _, err := search.splitCreated(ctx, base, from, mid, splitFollowers, partitions); err != nil {
+		return count, err
+	}
+	_, err = search.splitCreated(ctx, base, mid.AddDate(0, 0, 1), to, splitFollowers, partitions)
+	return count, err
+}
+
+// splitFollowers bisects a follower band, where a negative hi means no upper bound.
+func (search *userSearch) splitFollowers(ctx context.Context, base string, lo int, hi int, partitions *[]string) error {
+	qualifiers := fmt.Sprintf("%s followers:%d..%d", base, lo, hi)
+	if hi < 0 {
+		qualifiers = fmt.Sprintf("%s followers:>=%d", base, lo)
+	}
+	count, err := search.count(ctx, qualifiers)
+	if err != nil || count == 0 {
+		return err
+	}
+	if count <= searchResultCap || lo == hi {
+		if count > searchResultCap {
+			log.Printf("%d users match%s, only the first %d can be fetched", count, qualifiers, searchResultCap)
+		}
+		*partitions = append(*partitions, qualifiers)
+		return nil
+	}
+
+	mid := (lo + hi) / 2
+	if hi < 0 {
+		mid = lo*2 + 1
+	}
+	if err := search.splitFollowers(ctx, base, lo, mid, partitions); err != nil {
+		return err
+	}
+	return search.splitFollowers(ctx, base, mid+1, hi, partitions)
+}
+
+func (search *userSearch) queryString(qualifiers string) string {
+	return fmt.Sprintf("%s%s sort:%s-%s", search.query.Q, qualifiers, search.query.Sort, search.query.Order)
+}
+
+func (search *userSearch) count(ctx context.Context, qualifiers string) (int, error) {
+	body, err := search.client.GraphQL(ctx, GraphQLRequest{
+		Query:     searchUserCountQuery,
+		Variables: map[string]interface{}{"q": search.queryString(qualifiers)}})
+	if err != nil {
+		return 0, fmt.Errorf("error counting users: %w", err)
+	}
+
+	response := searchResponse{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("error unmarshalling search response: %w", err)
+	}
+	return response.Data.Search.UserCount, nil
+}
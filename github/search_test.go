@@ -0,0 +1,259 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"most-active-github-users-counter/net"
+)
+
+type fakeSearchUser struct {
+	login     string
+	followers int
+	created   string
+}
+
+// fakeSearchServer answers search queries like GitHub: users matching the
+// followers and created qualifiers, sorted by followers, of which only the
+// first searchResultCap can be paged through.
+func fakeSearchServer(t *testing.T, users []fakeSearchUser) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := struct {
+			Variables struct {
+				Q     string
+				First int
+				After string
+			}
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("error decoding request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		matching := []fakeSearchUser{}
+		for _, user := range users {
+			if matchesQualifiers(t, user, request.Variables.Q) {
+				matching = append(matching, user)
+			}
+		}
+		sort.SliceStable(matching, func(i, j int) bool {
+			return matching[i].followers > matching[j].followers
+		})
+
+		start := 0
+		if request.Variables.After != "" {
+			start, _ = strconv.Atoi(request.Variables.After)
+		}
+		edges := []map[string]interface{}{}
+		for i := start; i < len(matching) && i < start+request.Variables.First && i < searchResultCap; i++ {
+			edges = append(edges, map[string]interface{}{
+				"cursor": strconv.Itoa(i + 1),
+				"node": map[string]interface{}{
+					"__typename":              "User",
+					"login":                   matching[i].login,
+					"followers":               map[string]int{"totalCount": matching[i].followers},
+					"contributionsCollection": map[string]interface{}{},
+				}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"search": map[string]interface{}{"userCount": len(matching), "edges": edges}}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func matchesQualifiers(t *testing.T, user fakeSearchUser, q string) bool {
+	for _, term := range strings.Fields(q) {
+		if value := strings.TrimPrefix(term, "followers:"); value != term {
+			if !matchesFollowers(t, user.followers, value) {
+				return false
+			}
+		} else if value := strings.TrimPrefix(term, "created:"); value != term {
+			bounds := strings.Split(value, "..")
+			if user.created < bounds[0] || user.created > bounds[1] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchesFollowers(t *testing.T, followers int, value string) bool {
+	atoi := func(s string) int {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			t.Fatalf("invalid followers qualifier %q", value)
+		}
+		return n
+	}
+	switch {
+	case strings.HasPrefix(value, ">="):
+		return followers >= atoi(value[2:])
+	case strings.HasPrefix(value, "<"):
+		return followers < atoi(value[1:])
+	case strings.Contains(value, ".."):
+		bounds := strings.Split(value, "..")
+		return followers >= atoi(bounds[0]) && followers <= atoi(bounds[1])
+	}
+	return followers == atoi(value)
+}
+
+// redirectTo sends every request to server instead of the GitHub API.
+func redirectTo(server *httptest.Server) net.Wrapper {
+	target, _ := url.Parse(server.URL)
+	return func(r net.Requester) net.Requester {
+		return func(ctx context.Context, req *http.Request) (net.Response, error) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			return r(ctx, req)
+		}
+	}
+}
+
+// boundaryUsers are 1200 users of which 20 share the follower count found at
+// the result cap, and the first 1100 were created on the same day.
+func boundaryUsers() []fakeSearchUser {
+	users := []fakeSearchUser{}
+	for i := 0; i < 1200; i++ {
+		user := fakeSearchUser{login: fmt.Sprintf("user%04d", i), created: "2015-01-01"}
+		switch {
+		case i < 990:
+			user.followers = 2000 - i
+		case i < 1010:
+			user.followers = 500
+		default:
+			user.followers = 499 - (i - 1010)
+		}
+		if i >= 1100 {
+			user.created = time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, i-1100).Format("2006-01-02")
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+func TestSearchUsersKeepsBoundaryUsers(t *testing.T) {
+	users := boundaryUsers()
+	for _, partition := range []PartitionStrategy{PartitionByFollowers, PartitionByCreated} {
+		t.Run(string(partition), func(t *testing.T) {
+			client := NewGithubClient(redirectTo(fakeSearchServer(t, users)))
+			results, err := client.SearchUsers(context.Background(), UserSearchQuery{
+				Q:         "type:user",
+				Sort:      "followers",
+				Order:     "desc",
+				MaxUsers:  len(users) * 2,
+				Partition: partition})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			seen := map[string]int{}
+			for _, user := range results.Users {
+				seen[user.Login]++
+			}
+			for _, user := range users {
+				if seen[user.login] != 1 {
+					t.Errorf("%s (%d followers) returned %d times", user.login, user.followers, seen[user.login])
+				}
+			}
+			if len(results.Users) != len(users) {
+				t.Errorf("returned %d users, want %d", len(results.Users), len(users))
+			}
+		})
+	}
+}
+
+// failFirst fails the first request with a server error, as if GitHub was down.
+func failFirst() net.Wrapper {
+	failed := false
+	return func(r net.Requester) net.Requester {
+		return func(ctx context.Context, req *http.Request) (net.Response, error) {
+			if !failed {
+				failed = true
+				return net.Response{}, &net.StatusError{StatusCode: http.StatusBadGateway, URL: req.URL.String()}
+			}
+			return r(ctx, req)
+		}
+	}
+}
+
+func TestSearchUsersResumesAfterFailedFirstPage(t *testing.T) {
+	users := boundaryUsers()
+	query := UserSearchQuery{Q: "type:user", Sort: "followers", Order: "desc", MaxUsers: len(users) * 2}
+	client := NewGithubClient(redirectTo(fakeSearchServer(t, users)), failFirst())
+
+	// what a checkpoint holds when the search failed before its first page
+	cursor := SearchCursor{}
+	for page := range client.SearchUsersStream(context.Background(), query) {
+		if page.Err == nil {
+			t.Fatalf("got a page of %d users, want the first page to fail", len(page.Users))
+		}
+	}
+
+	query.Resume = &cursor
+	results, err := client.SearchUsers(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Users) != len(users) {
+		t.Errorf("resumed search returned %d users, want %d", len(results.Users), len(users))
+	}
+}
+
+func TestSearchUsersResumesFromCursor(t *testing.T) {
+	users := boundaryUsers()
+	for _, partition := range []PartitionStrategy{PartitionByFollowers, PartitionByCreated} {
+		t.Run(string(partition), func(t *testing.T) {
+			query := UserSearchQuery{Q: "type:user", Sort: "followers", Order: "desc", MaxUsers: len(users) * 2, Partition: partition}
+			client := NewGithubClient(redirectTo(fakeSearchServer(t, users)))
+
+			// stop half way, keeping what a checkpoint would
+			ctx, cancel := context.WithCancel(context.Background())
+			seen := map[string]int{}
+			var cursor SearchCursor
+			for page := range client.SearchUsersStream(ctx, query) {
+				if page.Err != nil {
+					t.Fatal(page.Err)
+				}
+				for _, user := range page.Users {
+					seen[user.Login]++
+				}
+				cursor = page.Cursor
+				if cursor.Fetched >= len(users)/2 {
+					cancel()
+					break
+				}
+			}
+			cancel()
+
+			query.Resume = &cursor
+			query.SeenLogins = map[string]bool{}
+			for login := range seen {
+				query.SeenLogins[login] = true
+			}
+			results, err := client.SearchUsers(context.Background(), query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, user := range results.Users {
+				seen[user.Login]++
+			}
+			for _, user := range users {
+				if seen[user.login] != 1 {
+					t.Errorf("%s returned %d times", user.login, seen[user.login])
+				}
+			}
+		})
+	}
+}
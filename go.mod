@@ -2,3 +2,4 @@ module most-active-github-users-counter
 
 go 1.17
 
+require gopkg.in/yaml.v3 v3.0.1
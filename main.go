@@ -2,13 +2,19 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"most-active-github-users-counter/company"
 	"most-active-github-users-counter/github"
+	"most-active-github-users-counter/net"
 	"most-active-github-users-counter/output"
 	"most-active-github-users-counter/top"
 )
@@ -32,29 +38,119 @@ var presetChecksum string
 func main() {
 	token := flag.String("token", LookupEnvOrString("GITHUB_TOKEN", ""), "Github auth token")
 	amount := flag.Int("amount", 256, "Amount of users to show")
+	orgAmount := flag.Int("org-amount", 10, "Amount of organizations to show")
+	orgRankBy := flag.String("org-rank-by", "", "Rank organizations by \"members\" instead of each leaderboard's metric summed over their members (optional)")
 	considerNum := flag.Int("consider", 1000, "Amount of users to consider")
-	outputOpt := flag.String("output", "plain", "Output format: plain, csv")
+	outputOpt := flag.String("output", "plain", "Output format: plain, csv, yaml, json")
 	fileName := flag.String("file", "", "Output file (optional, defaults to stdout)")
 	presetName := flag.String("preset", "", "Preset (optional)")
 	listPresets := flag.Bool("list-presets", false, "List all available presets as CSV and exit immediately")
+	rankBy := flag.String("rank-by", "", "Metric to rank users by, see --list-metrics (optional)")
+	scoreFormula := flag.String("score", "", "Weighted formula ranked as the \"score\" metric, e.g. \"commits*1 + prs*3 + reviews*2\" (optional)")
+	tieBreak := flag.String("tie-break", "followers,login", "Comma separated metrics, or login, ordering users and organizations with equal values")
+	competitionRanks := flag.Bool("competition-ranks", false, "Give tied users and organizations the same rank, as in \"1, 2, 2, 4\"")
+	rankOnly := flag.String("rank-only", "", "Aggregate the JSON results of each preset in this directory, named <preset>.json, into the badges' rank_only.json and exit immediately")
+	listMetrics := flag.Bool("list-metrics", false, "List all available ranking metrics as CSV and exit immediately")
+	companyAliases := flag.String("company-aliases", "", "JSON file mapping company names to organization logins, e.g. {\"Google LLC\": \"google\"} (optional)")
+	verifyCompanies := flag.Bool("verify-companies", false, "Check that organizations named in company fields exist, costing one API request per organization")
+	recordDir := flag.String("record", "", "Record API responses as fixtures in this directory (optional)")
+	replayDir := flag.String("replay", "", "Replay API responses recorded with --record from this directory instead of querying GitHub (optional)")
+	cacheDir := flag.String("cache-dir", "", "Cache API responses in this directory (optional)")
+	cacheTTL := flag.Duration("cache-ttl", 24*time.Hour, "How long responses in --cache-dir stay valid")
+	partition := flag.String("partition", "followers", "How to split searches larger than 1000 users: followers, or created to enumerate every user (oldest accounts first)")
+	checkpointFile := flag.String("checkpoint", "", "Periodically save search progress to this file (optional)")
+	resume := flag.Bool("resume", false, "Continue an interrupted search from the --checkpoint file")
+	since := flag.String("since", "", "Count contributions from this date, e.g. 2025-01-01 (optional, defaults to a year ago)")
+	until := flag.String("until", "", "Count contributions up to and including this date, e.g. 2025-12-31 (optional)")
+	history := flag.Bool("history", false, "Fetch yearly contribution history for each user, costing two API requests per user")
+	timeout := flag.Duration("timeout", 0, "Abort the run after this long and write partial results (optional)")
 
 	flag.Var(&locations, "location", "Location to query")
 	flag.Parse()
 
+	score := ""
+	if *scoreFormula != "" {
+		var err error
+		if score, err = output.RegisterScore(*scoreFormula); err != nil {
+			log.Fatal(err)
+		}
+		if *rankBy == "" {
+			*rankBy = output.ScoreMetric
+		}
+	}
+
 	if *listPresets {
 		fmt.Println("preset,title,definition_checksum")
 		for name := range PRESETS {
-			fmt.Printf("%v,\"%v\",%v\n", name, PresetTitle(name), PresetChecksum(name))
+			fmt.Printf("%v,\"%v\",%v\n", name, PresetTitle(name), DefinitionChecksum(name, score))
+		}
+		return
+	}
+
+	if *listMetrics {
+		fmt.Println("metric,description")
+		for _, metric := range output.Metrics() {
+			fmt.Printf("%v,\"%v\"\n", metric.Name, metric.Description)
+		}
+		return
+	}
+
+	if *rankOnly != "" {
+		documents, err := output.ReadDocuments(*rankOnly)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(documents) == 0 {
+			log.Fatalf("no results found in %s", *rankOnly)
+		}
+		writer := os.Stdout
+		if *fileName != "" {
+			if writer, err = os.Create(*fileName); err != nil {
+				log.Fatal(err)
+			}
+			defer writer.Close()
+		}
+		if err := output.RankOnly(documents, writer); err != nil {
+			log.Fatal(err)
 		}
 		return
 	}
 
+	if *rankBy != "" {
+		if _, err := output.LookupMetric(*rankBy); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if *orgRankBy != "" && *orgRankBy != output.MembersMetric {
+		log.Fatalf("Unrecognized organization ranking: %s", *orgRankBy)
+	}
+
+	tieBreakers := []string{}
+	for _, name := range strings.Split(*tieBreak, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, err := output.LookupTieBreaker(name); err != nil {
+			log.Fatal(err)
+		}
+		tieBreakers = append(tieBreakers, name)
+	}
+
 	if *presetName != "" {
 		preset := Preset(*presetName)
 		locations = preset.include
 		excludeLocations = preset.exclude
 		presetTitle = PresetTitle(*presetName)
-		presetChecksum = PresetChecksum(*presetName)
+		presetChecksum = DefinitionChecksum(*presetName, score)
+	}
+
+	aliases := map[string]string{}
+	if *companyAliases != "" {
+		var err error
+		if aliases, err = company.LoadAliases(*companyAliases); err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	var format output.Format
@@ -65,19 +161,54 @@ func main() {
 		format = output.YamlOutput
 	} else if *outputOpt == "csv" {
 		format = output.CsvOutput
+	} else if *outputOpt == "json" {
+		format = output.JsonOutput
 	} else {
 		log.Fatal("Unrecognized output format: ", *outputOpt)
 	}
 
-	opts := top.Options{Token: *token, Locations: locations, ExcludeLocations: excludeLocations, Amount: *amount, ConsiderNum: *considerNum, PresetTitle: presetTitle, PresetChecksum: presetChecksum, Filter: func(u github.User) bool {
-		return !strings.Contains(strings.ToLower(u.Company), "github")
-	}}
-	data, err := top.GithubTop(opts)
+	partitionStrategy := github.PartitionStrategy(*partition)
+	if partitionStrategy != github.PartitionByFollowers && partitionStrategy != github.PartitionByCreated {
+		log.Fatal("Unrecognized partition strategy: ", *partition)
+	}
 
+	from, to, err := contributionWindow(*since, *until)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	var wrappers []net.Wrapper
+	if *recordDir != "" && *replayDir != "" {
+		log.Fatal("--record and --replay cannot be used together")
+	} else if *recordDir != "" {
+		wrappers = append(wrappers, net.Record(*recordDir))
+	} else if *replayDir != "" {
+		wrappers = append(wrappers, net.Replay(*replayDir))
+	}
+	if *cacheDir != "" {
+		wrappers = append(wrappers, net.Cache(*cacheDir, *cacheTTL))
+	}
+
+	opts := top.Options{Token: *token, Locations: locations, ExcludeLocations: excludeLocations, Amount: *amount, ConsiderNum: *considerNum, PresetTitle: presetTitle, PresetChecksum: presetChecksum, Filter: func(u github.User) bool {
+		return !strings.Contains(strings.ToLower(u.Company), "github")
+	}, Partition: partitionStrategy, Wrappers: wrappers, Offline: *replayDir != "", CheckpointFile: *checkpointFile, Resume: *resume, From: from, To: to, History: *history, RankBy: *rankBy, Score: score, TieBreakers: tieBreakers, CompetitionRanks: *competitionRanks, OrgAmount: *orgAmount, OrgRankBy: *orgRankBy, Companies: company.NewNormaliser(aliases), VerifyCompanies: *verifyCompanies}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	data, runErr := top.GithubTop(ctx, opts)
+
+	// a cancelled run still writes whatever users were collected, but exits with an error
+	interrupted := runErr != nil && ctx.Err() != nil && len(data.Users) > 0
+	if runErr != nil && !interrupted {
+		log.Fatal(runErr)
+	}
+
 	var writer *bufio.Writer
 	if *fileName != "" {
 		f, err := os.Create(*fileName)
@@ -95,6 +226,37 @@ func main() {
 		log.Fatal(err)
 	}
 	writer.Flush()
+
+	if interrupted {
+		log.Fatalf("run interrupted, wrote partial results for %d users: %v", len(data.Users), runErr)
+	}
+}
+
+// contributionWindow parses the --since and --until dates. GitHub counts
+// contributions over at most one year.
+func contributionWindow(since string, until string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	var err error
+	if since != "" {
+		if from, err = time.Parse("2006-01-02", since); err != nil {
+			return from, to, fmt.Errorf("invalid --since date: %w", err)
+		}
+	}
+	if until != "" {
+		if to, err = time.Parse("2006-01-02", until); err != nil {
+			return from, to, fmt.Errorf("invalid --until date: %w", err)
+		}
+		to = to.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	if !from.IsZero() && !to.IsZero() {
+		if to.Before(from) {
+			return from, to, fmt.Errorf("--until %s is before --since %s", until, since)
+		}
+		if to.After(from.AddDate(1, 0, 0)) {
+			return from, to, fmt.Errorf("--since %s and --until %s span more than one year", since, until)
+		}
+	}
+	return from, to, nil
 }
 
 func LookupEnvOrString(key string, defaultVal string) string {
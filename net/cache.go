@@ -0,0 +1,77 @@
+package net
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type cacheEntry struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status"`
+	Response   string `json:"response"`
+}
+
+// Cache serves successful responses from dir while they are younger than ttl
+// and stores fresh ones there. Entries are keyed by URL and a hash of the body.
+func Cache(dir string, ttl time.Duration) Wrapper {
+	return func(r Requester) Requester {
+		return func(ctx context.Context, req *http.Request) (Response, error) {
+			body, err := readBody(req)
+			if err != nil {
+				return Response{}, err
+			}
+			path := filepath.Join(dir, cacheKey(req.URL.String(), body)+".json")
+
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < ttl {
+				if resp, err := readCacheEntry(path); err == nil {
+					return resp, nil
+				}
+			}
+
+			resp, err := r(ctx, req)
+			if err != nil {
+				return resp, err
+			}
+			if err := writeCacheEntry(dir, path, cacheEntry{URL: req.URL.String(), StatusCode: resp.StatusCode, Response: string(resp.Body)}); err != nil {
+				// a failed write only costs a later cache miss
+				log.Printf("error writing cache entry %s: %v", path, err)
+			}
+			return resp, nil
+		}
+	}
+}
+
+func cacheKey(url string, body []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(fmt.Sprintf("%s\n%x", url, sha256.Sum256(body)))))
+}
+
+func readCacheEntry(path string) (Response, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Response{}, err
+	}
+	entry := cacheEntry{}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Response{}, err
+	}
+	return Response{StatusCode: entry.StatusCode, Header: http.Header{}, Body: []byte(entry.Response)}, nil
+}
+
+func writeCacheEntry(dir string, path string, entry cacheEntry) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
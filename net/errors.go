@@ -0,0 +1,79 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const maxSnippetLength = 200
+
+// StatusError is returned by MakeRequester for responses with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	URL        string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned %d %s: %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode), e.Snippet())
+}
+
+// Snippet returns the start of the response body on a single line.
+func (e *StatusError) Snippet() string {
+	snippet := strings.Join(strings.Fields(string(e.Body)), " ")
+	if len(snippet) > maxSnippetLength {
+		snippet = snippet[:maxSnippetLength] + "..."
+	}
+	return snippet
+}
+
+func (e *StatusError) response() Response {
+	return Response{StatusCode: e.StatusCode, Header: e.Header, Body: e.Body}
+}
+
+func asStatusError(err error) (*StatusError, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr, true
+	}
+	return nil, false
+}
+
+// rateLimitedError is implemented by errors that report a rate limit
+// without an HTTP status, such as GraphQL errors.
+type rateLimitedError interface {
+	RateLimited() bool
+}
+
+// retryableError is implemented by errors that know whether resending the
+// request may succeed.
+type retryableError interface {
+	Retryable() bool
+}
+
+func IsRateLimited(err error) bool {
+	var limited rateLimitedError
+	if errors.As(err, &limited) && limited.RateLimited() {
+		return true
+	}
+	statusErr, ok := asStatusError(err)
+	return ok && isRateLimitResponse(statusErr.response())
+}
+
+func IsUnauthorized(err error) bool {
+	statusErr, ok := asStatusError(err)
+	return ok && statusErr.StatusCode == http.StatusUnauthorized
+}
+
+func IsNotFound(err error) bool {
+	statusErr, ok := asStatusError(err)
+	return ok && statusErr.StatusCode == http.StatusNotFound
+}
+
+func IsServerError(err error) bool {
+	statusErr, ok := asStatusError(err)
+	return ok && statusErr.StatusCode >= http.StatusInternalServerError
+}
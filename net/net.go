@@ -1,10 +1,18 @@
 package net
 
+import "context"
 import "net/http"
 import "fmt"
 import "io/ioutil"
+import "time"
 
-type Requester func(req *http.Request) ([]byte, error)
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type Requester func(ctx context.Context, req *http.Request) (Response, error)
 
 type Wrapper func(Requester) Requester
 
@@ -19,26 +27,56 @@ func Compose(wrappers ...Wrapper) Wrapper {
 
 func TokenAuth(token string) Wrapper {
 	return func(r Requester) Requester {
-		return func(req *http.Request) ([]byte, error) {
-			req.Header.Add("Authorization", fmt.Sprintf("bearer %s", token))
-			return r(req)
+		return func(ctx context.Context, req *http.Request) (Response, error) {
+			req.Header.Set("Authorization", fmt.Sprintf("bearer %s", token))
+			return r(ctx, req)
 		}
 	}
 }
 
 func MakeRequester(client *http.Client) Requester {
-	return func(req *http.Request) ([]byte, error) {
-		resp, err := client.Do(req)
+	return func(ctx context.Context, req *http.Request) (Response, error) {
+		resp, err := client.Do(req.WithContext(ctx))
 
 		if err != nil {
-			return []byte{}, err
+			return Response{}, err
 		}
+		defer resp.Body.Close()
 
 		bodyText, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return []byte{}, err
+			return Response{}, err
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return Response{}, &StatusError{StatusCode: resp.StatusCode, Header: resp.Header, Body: bodyText, URL: req.URL.String()}
 		}
 
-		return bodyText, nil
+		return Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: bodyText}, nil
+	}
+}
+
+// rewind resets the request body so that the request can be sent again.
+func rewind(req *http.Request) error {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+// sleep waits for d, returning early with the context error if ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
 }
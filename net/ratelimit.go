@@ -0,0 +1,137 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// secondaryLimitPause is how long GitHub asks clients to back off after
+// hitting a secondary rate limit that carries no Retry-After header.
+const secondaryLimitPause = time.Minute
+
+const maxRateLimitWaits = 5
+
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// RateLimiter tracks the API budget reported by GitHub and pauses requests
+// until the window resets once the budget drops to MinRemaining.
+type RateLimiter struct {
+	MinRemaining int
+
+	mu         sync.Mutex
+	budget     RateLimit
+	known      bool
+	pauseUntil time.Time
+	now        func() time.Time
+	sleep      func(context.Context, time.Duration) error
+}
+
+func NewRateLimiter(minRemaining int) *RateLimiter {
+	return &RateLimiter{MinRemaining: minRemaining, now: time.Now, sleep: sleep}
+}
+
+// Budget returns the most recently reported rate limit, and false if no
+// response carrying rate limit headers has been seen yet.
+func (limiter *RateLimiter) Budget() (RateLimit, bool) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return limiter.budget, limiter.known
+}
+
+func (limiter *RateLimiter) wait(ctx context.Context) error {
+	limiter.mu.Lock()
+	pause := limiter.pauseUntil.Sub(limiter.now())
+	limiter.mu.Unlock()
+
+	if pause > 0 {
+		log.Printf("rate limit reached, pausing for %v", pause.Round(time.Second))
+		return limiter.sleep(ctx, pause)
+	}
+	return nil
+}
+
+// observe records the rate limit headers of resp and reports whether the
+// request was rejected because of a rate limit, which rejected forces.
+func (limiter *RateLimiter) observe(resp Response, rejected bool) bool {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+
+	now := limiter.now()
+	if budget, ok := parseRateLimit(resp.Header); ok {
+		limiter.budget = budget
+		limiter.known = true
+		if budget.Remaining <= limiter.MinRemaining && budget.Reset.After(limiter.pauseUntil) {
+			limiter.pauseUntil = budget.Reset
+		}
+	}
+
+	if !rejected && !isRateLimitResponse(resp) {
+		return false
+	}
+
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		limiter.pauseUntil = now.Add(time.Duration(retryAfter) * time.Second)
+	} else if !limiter.pauseUntil.After(now) {
+		limiter.pauseUntil = now.Add(secondaryLimitPause)
+	}
+	return true
+}
+
+func parseRateLimit(header http.Header) (RateLimit, bool) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return RateLimit{}, false
+	}
+	limit, _ := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	reset, _ := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	return RateLimit{Limit: limit, Remaining: remaining, Reset: time.Unix(reset, 0)}, true
+}
+
+func isRateLimitResponse(resp Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return resp.Header.Get("Retry-After") != "" ||
+			resp.Header.Get("X-RateLimit-Remaining") == "0" ||
+			bytes.Contains(bytes.ToLower(resp.Body), []byte("rate limit"))
+	}
+	return false
+}
+
+// RateLimitAware pauses requests while the budget tracked by limiter is
+// exhausted and resends requests rejected by a primary or secondary rate limit.
+func RateLimitAware(limiter *RateLimiter) Wrapper {
+	return func(r Requester) Requester {
+		return func(ctx context.Context, req *http.Request) (Response, error) {
+			for waits := 0; ; waits++ {
+				if err := limiter.wait(ctx); err != nil {
+					return Response{}, err
+				}
+				resp, err := r(ctx, req)
+				observed := resp
+				if statusErr, ok := asStatusError(err); ok {
+					observed = statusErr.response()
+				}
+				var limited rateLimitedError
+				rejected := errors.As(err, &limited) && limited.RateLimited()
+				if !limiter.observe(observed, rejected) || waits >= maxRateLimitWaits {
+					return resp, err
+				}
+				if err := rewind(req); err != nil {
+					return resp, err
+				}
+			}
+		}
+	}
+}
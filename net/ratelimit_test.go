@@ -0,0 +1,134 @@
+package net
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeClock replaces the time and sleeping of a RateLimiter, recording pauses
+// instead of waiting for them.
+type fakeClock struct {
+	now    time.Time
+	pauses []time.Duration
+}
+
+func (clock *fakeClock) limiter() *RateLimiter {
+	limiter := NewRateLimiter(0)
+	limiter.now = func() time.Time { return clock.now }
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		clock.pauses = append(clock.pauses, d)
+		clock.now = clock.now.Add(d)
+		return nil
+	}
+	return limiter
+}
+
+// serve answers each request with the next of responses, which write the
+// headers and status of a response.
+func serve(t *testing.T, responses ...func(w http.ResponseWriter)) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests >= len(responses) {
+			t.Errorf("unexpected request %d", requests+1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		responses[requests](w)
+		requests++
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func get(t *testing.T, requester Requester, url string) (Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return requester(context.Background(), req)
+}
+
+func ok(w http.ResponseWriter) {
+	fmt.Fprint(w, `{}`)
+}
+
+func TestRateLimitAwareRetryAfter(t *testing.T) {
+	server, requests := serve(t, func(w http.ResponseWriter) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit."}`)
+	}, ok)
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	resp, err := get(t, RateLimitAware(clock.limiter())(MakeRequester(server.Client())), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || *requests != 2 {
+		t.Errorf("got status %d after %d requests, want 200 after 2", resp.StatusCode, *requests)
+	}
+	if len(clock.pauses) != 1 || clock.pauses[0] != 7*time.Second {
+		t.Errorf("paused %v, want [7s]", clock.pauses)
+	}
+}
+
+func TestRateLimitAwareSecondaryLimit(t *testing.T) {
+	server, requests := serve(t, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`)
+	}, ok)
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+
+	resp, err := get(t, RateLimitAware(clock.limiter())(MakeRequester(server.Client())), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || *requests != 2 {
+		t.Errorf("got status %d after %d requests, want 200 after 2", resp.StatusCode, *requests)
+	}
+	if len(clock.pauses) != 1 || clock.pauses[0] != secondaryLimitPause {
+		t.Errorf("paused %v, want [%v]", clock.pauses, secondaryLimitPause)
+	}
+}
+
+func TestRateLimitAwareExhaustedBudget(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	reset := clock.now.Add(30 * time.Second)
+	server, requests := serve(t, func(w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+		ok(w)
+	}, func(w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset.Add(time.Hour).Unix()))
+		ok(w)
+	})
+	limiter := clock.limiter()
+	requester := RateLimitAware(limiter)(MakeRequester(server.Client()))
+
+	// the last request of the budget succeeds, the next one waits for the reset
+	if _, err := get(t, requester, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if len(clock.pauses) != 0 {
+		t.Errorf("paused %v before the budget was exhausted", clock.pauses)
+	}
+	if _, err := get(t, requester, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if *requests != 2 {
+		t.Errorf("sent %d requests, want 2", *requests)
+	}
+	if len(clock.pauses) != 1 || clock.pauses[0] != 30*time.Second {
+		t.Errorf("paused %v, want [30s]", clock.pauses)
+	}
+	if budget, known := limiter.Budget(); !known || budget.Remaining != 4999 {
+		t.Errorf("budget %+v (known %v), want 4999 remaining", budget, known)
+	}
+}
@@ -0,0 +1,151 @@
+package net
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoFixture is returned by Replay for requests that were never recorded.
+var ErrNoFixture = errors.New("no recorded response")
+
+// fixture is a recorded request/response pair as stored on disk.
+type fixture struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Body       string      `json:"body,omitempty"`
+	StatusCode int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Response   string      `json:"response"`
+}
+
+// Record passes requests through and writes every response, including
+// non-2xx ones, to a fixture file in dir that Replay can serve later.
+func Record(dir string) Wrapper {
+	return func(r Requester) Requester {
+		return func(ctx context.Context, req *http.Request) (Response, error) {
+			key, body, err := requestKey(req)
+			if err != nil {
+				return Response{}, err
+			}
+
+			resp, err := r(ctx, req)
+			recorded := resp
+			if statusErr, ok := asStatusError(err); ok {
+				recorded = statusErr.response()
+			} else if err != nil {
+				return resp, err
+			}
+
+			f := fixture{
+				Method:     req.Method,
+				URL:        req.URL.String(),
+				Body:       string(body),
+				StatusCode: recorded.StatusCode,
+				Header:     recorded.Header,
+				Response:   string(recorded.Body)}
+			if writeErr := writeFixture(dir, key, f); writeErr != nil {
+				return resp, writeErr
+			}
+			return resp, err
+		}
+	}
+}
+
+// Replay serves responses recorded by Record from dir without sending any
+// request. Requests are matched by method, URL and normalised body.
+func Replay(dir string) Wrapper {
+	return func(Requester) Requester {
+		return func(ctx context.Context, req *http.Request) (Response, error) {
+			key, _, err := requestKey(req)
+			if err != nil {
+				return Response{}, err
+			}
+
+			data, err := ioutil.ReadFile(fixturePath(dir, key))
+			if os.IsNotExist(err) {
+				return Response{}, fmt.Errorf("%w for %s %s in %s", ErrNoFixture, req.Method, req.URL, dir)
+			} else if err != nil {
+				return Response{}, err
+			}
+
+			f := fixture{}
+			if err := json.Unmarshal(data, &f); err != nil {
+				return Response{}, fmt.Errorf("invalid fixture %s: %w", fixturePath(dir, key), err)
+			}
+			if f.StatusCode < 200 || f.StatusCode > 299 {
+				return Response{}, &StatusError{StatusCode: f.StatusCode, Header: f.Header, Body: []byte(f.Response), URL: f.URL}
+			}
+			return Response{StatusCode: f.StatusCode, Header: f.Header, Body: []byte(f.Response)}, nil
+		}
+	}
+}
+
+func writeFixture(dir string, key string, f fixture) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fixturePath(dir, key), data, 0644)
+}
+
+func fixturePath(dir string, key string) string {
+	return filepath.Join(dir, key+".json")
+}
+
+// requestKey identifies a request by its method, URL and normalised body.
+// The body is returned as well, and left readable on req.
+func requestKey(req *http.Request) (string, []byte, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return "", nil, err
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n%s", req.Method, req.URL, normaliseBody(body))
+	return fmt.Sprintf("%x", hash.Sum(nil)), body, nil
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// normaliseBody re-encodes JSON bodies with sorted keys and collapses runs of
+// whitespace, so that formatting changes in query text do not change the key.
+func normaliseBody(body []byte) string {
+	var decoded interface{}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		if canonical, err := json.Marshal(decoded); err == nil {
+			body = canonical
+		}
+	}
+	return strings.Join(strings.Fields(string(body)), " ")
+}
@@ -0,0 +1,67 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	server, requests := serve(t, func(w http.ResponseWriter) {
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		fmt.Fprint(w, `{"data": {"viewer": {"login": "octocat"}}}`)
+	}, func(w http.ResponseWriter) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+	})
+	dir := t.TempDir()
+
+	post := func(requester Requester, url string, body string) (Response, error) {
+		req, err := http.NewRequest("POST", url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return requester(context.Background(), req)
+	}
+
+	recorder := Record(dir)(MakeRequester(server.Client()))
+	if _, err := post(recorder, server.URL+"/graphql", `{"query": "{ viewer { login } }", "variables": {"a": 1, "b": 2}}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := get(t, recorder, server.URL+"/users/nobody"); !IsNotFound(err) {
+		t.Fatalf("got %v, want a recorded 404", err)
+	}
+
+	replayer := Replay(dir)(func(ctx context.Context, req *http.Request) (Response, error) {
+		t.Errorf("replay sent %s %s", req.Method, req.URL)
+		return Response{}, errors.New("unexpected request")
+	})
+
+	// formatting and key order of a JSON body don't matter
+	resp, err := post(replayer, server.URL+"/graphql", `{"variables":{"b":2,"a":1},"query":"{  viewer {  login } }"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != `{"data": {"viewer": {"login": "octocat"}}}` || resp.StatusCode != http.StatusOK {
+		t.Errorf("replayed %d %s", resp.StatusCode, resp.Body)
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "4999" {
+		t.Errorf("replayed headers %v", resp.Header)
+	}
+
+	_, err = get(t, replayer, server.URL+"/users/nobody")
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound || !strings.Contains(string(statusErr.Body), "Not Found") {
+		t.Errorf("got %v, want the recorded 404", err)
+	}
+
+	if _, err := post(replayer, server.URL+"/graphql", `{"query": "{ viewer { name } }"}`); !errors.Is(err, ErrNoFixture) {
+		t.Errorf("got %v for a request that wasn't recorded, want ErrNoFixture", err)
+	}
+	if *requests != 2 {
+		t.Errorf("server got %d requests, want the 2 recorded ones", *requests)
+	}
+}
@@ -0,0 +1,74 @@
+package net
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	// Jitter is the fraction (0-1) of each delay that is randomised.
+	Jitter float64
+}
+
+var DefaultBackoff = Backoff{Initial: 2 * time.Second, Max: time.Minute, Multiplier: 2, Jitter: 0.5}
+
+// Delay returns how long to wait before the given retry, counting from zero.
+func (b Backoff) Delay(retry int) time.Duration {
+	delay := float64(b.Initial) * math.Pow(b.Multiplier, float64(retry))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		delay -= delay * b.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+type RetryPolicy func(err error) bool
+
+// DefaultRetryPolicy retries rate limited requests, server errors and errors
+// that did not come from an HTTP status, such as connection failures, unless
+// the error reports itself as permanent.
+func DefaultRetryPolicy(err error) bool {
+	if errors.Is(err, ErrNoFixture) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if _, ok := asStatusError(err); ok {
+		return IsRateLimited(err) || IsServerError(err)
+	}
+	var retryable retryableError
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+	return true
+}
+
+func Retry(attempts int, backoff Backoff, policy RetryPolicy) Wrapper {
+	return func(r Requester) Requester {
+		return func(ctx context.Context, req *http.Request) (Response, error) {
+			for attempt := 1; ; attempt++ {
+				resp, err := r(ctx, req)
+				if err == nil || attempt >= attempts || !policy(err) {
+					return resp, err
+				}
+
+				delay := backoff.Delay(attempt - 1)
+				log.Printf("request failed (attempt %d/%d), retrying in %v: %v", attempt, attempts, delay.Round(time.Millisecond), err)
+				if err := sleep(ctx, delay); err != nil {
+					return resp, err
+				}
+				if err := rewind(req); err != nil {
+					return resp, err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"most-active-github-users-counter/github"
+	"most-active-github-users-counter/top"
+)
+
+// Document is the machine readable result of a run, written by JsonOutput.
+type Document struct {
+	Metadata Metadata `json:"metadata"`
+	// Users are all the users considered, in the order they were found.
+	Users []UserEntry `json:"users"`
+	// Leaderboards rank the users by the commits, public and total metrics,
+	// followed by the metric selected with --rank-by if it is another one.
+	Leaderboards []Leaderboard `json:"leaderboards"`
+}
+
+type Metadata struct {
+	Generated            time.Time `json:"generated"`
+	MinFollowersRequired int       `json:"min_followers_required"`
+	TotalUserCount       int       `json:"total_user_count"`
+	// Title and DefinitionChecksum are only set for presets.
+	Title              string `json:"title,omitempty"`
+	DefinitionChecksum string `json:"definition_checksum,omitempty"`
+	Query              string `json:"query"`
+	// ContributionsFrom and ContributionsTo are only set when given, GitHub
+	// counts the last year otherwise.
+	ContributionsFrom *time.Time `json:"contributions_from,omitempty"`
+	ContributionsTo   *time.Time `json:"contributions_to,omitempty"`
+	ScoreFormula      string     `json:"score_formula,omitempty"`
+}
+
+type UserEntry struct {
+	Login                      string         `json:"login"`
+	Name                       string         `json:"name"`
+	AvatarURL                  string         `json:"avatar_url"`
+	Company                    string         `json:"company"`
+	Organizations              []string       `json:"organizations"`
+	Followers                  int            `json:"followers"`
+	Contributions              int            `json:"contributions"`
+	PublicContributions        int            `json:"public_contributions"`
+	PrivateContributions       int            `json:"private_contributions"`
+	Commits                    int            `json:"commits"`
+	PullRequests               int            `json:"pull_requests"`
+	Issues                     int            `json:"issues"`
+	Reviews                    int            `json:"reviews"`
+	RepositoriesCreated        int            `json:"repositories_created"`
+	CommitRepositories         int            `json:"commit_repositories"`
+	IssueRepositories          int            `json:"issue_repositories"`
+	PullRequestRepositories    int            `json:"pull_request_repositories"`
+	ReviewRepositories         int            `json:"review_repositories"`
+	HasRestrictedContributions bool           `json:"has_restricted_contributions"`
+	History                    []HistoryEntry `json:"history,omitempty"`
+}
+
+type HistoryEntry struct {
+	Year                 int `json:"year"`
+	Contributions        int `json:"contributions"`
+	PublicContributions  int `json:"public_contributions"`
+	PrivateContributions int `json:"private_contributions"`
+	Commits              int `json:"commits"`
+	PullRequests         int `json:"pull_requests"`
+	Issues               int `json:"issues"`
+	Reviews              int `json:"reviews"`
+}
+
+type Leaderboard struct {
+	Metric        string              `json:"metric"`
+	Description   string              `json:"description"`
+	Users         []RankedUserEntry   `json:"users"`
+	Organizations []OrganizationEntry `json:"organizations"`
+}
+
+type RankedUserEntry struct {
+	Rank int `json:"rank"`
+	// Value is the user's score in the leaderboard's metric.
+	Value int `json:"value"`
+	UserEntry
+}
+
+type OrganizationEntry struct {
+	Rank int `json:"rank"`
+	// Value is the leaderboard's metric summed over the organization's
+	// members, or its member count if organizations are ranked by members.
+	Value               int      `json:"value"`
+	Name                string   `json:"name"`
+	MemberCount         int      `json:"member_count"`
+	Commits             int      `json:"commits"`
+	PublicContributions int      `json:"public_contributions"`
+	Contributions       int      `json:"contributions"`
+	TopMembers          []string `json:"top_members"`
+}
+
+// leaderboardMetrics are ranked in every document.
+var leaderboardMetrics = []string{"commits", "public", "total"}
+
+// NewDocument ranks results into a Document.
+func NewDocument(results github.GithubSearchResults, options top.Options) (Document, error) {
+	order, err := rankOrder(options)
+	if err != nil {
+		return Document{}, err
+	}
+
+	names := leaderboardMetrics
+	if options.RankBy != "" && !contains(names, options.RankBy) {
+		names = append(append([]string{}, names...), options.RankBy)
+	}
+
+	document := Document{
+		Metadata: Metadata{
+			Generated:            time.Now(),
+			MinFollowersRequired: results.MinimumFollowerCount,
+			TotalUserCount:       results.TotalUserCount,
+			Query:                top.Query(options),
+			ScoreFormula:         options.Score},
+		Users:        []UserEntry{},
+		Leaderboards: []Leaderboard{}}
+	if options.PresetTitle != "" && options.PresetChecksum != "" {
+		document.Metadata.Title = options.PresetTitle
+		document.Metadata.DefinitionChecksum = options.PresetChecksum
+	}
+	if !options.From.IsZero() {
+		document.Metadata.ContributionsFrom = &options.From
+	}
+	if !options.To.IsZero() {
+		document.Metadata.ContributionsTo = &options.To
+	}
+
+	for _, user := range results.Users {
+		document.Users = append(document.Users, newUserEntry(user))
+	}
+
+	for _, name := range names {
+		metric, err := LookupMetric(name)
+		if err != nil {
+			return Document{}, err
+		}
+		orgsBy, err := orgMetric(options, metric)
+		if err != nil {
+			return Document{}, err
+		}
+		ranking := Rank(results.Users, metric, options.Amount, order)
+		leaderboard := Leaderboard{
+			Metric:        metric.Name,
+			Description:   metric.Description,
+			Users:         []RankedUserEntry{},
+			Organizations: []OrganizationEntry{}}
+		for _, user := range ranking {
+			leaderboard.Users = append(leaderboard.Users, RankedUserEntry{Rank: user.Rank, Value: user.Value, UserEntry: newUserEntry(user.User)})
+		}
+		for _, org := range ranking.Users().TopOrgs(orgAmount(options), orgsBy, order, options.Companies) {
+			leaderboard.Organizations = append(leaderboard.Organizations, OrganizationEntry{
+				Rank:                org.Rank,
+				Value:               org.Value,
+				Name:                org.Name,
+				MemberCount:         org.MemberCount,
+				Commits:             org.CommitsCount,
+				PublicContributions: org.PublicContributionCount,
+				Contributions:       org.ContributionCount,
+				TopMembers:          org.TopMembers})
+		}
+		document.Leaderboards = append(document.Leaderboards, leaderboard)
+	}
+	return document, nil
+}
+
+func newUserEntry(user github.User) UserEntry {
+	entry := UserEntry{
+		Login:                      user.Login,
+		Name:                       user.Name,
+		AvatarURL:                  user.AvatarURL,
+		Company:                    user.Company,
+		Organizations:              append([]string{}, user.Organizations...),
+		Followers:                  user.FollowerCount,
+		Contributions:              user.ContributionCount,
+		PublicContributions:        user.PublicContributionCount,
+		PrivateContributions:       user.PrivateContributionCount,
+		Commits:                    user.CommitsCount,
+		PullRequests:               user.PullRequestsCount,
+		Issues:                     user.IssuesCount,
+		Reviews:                    user.PullRequestReviewsCount,
+		RepositoriesCreated:        user.RepositoriesCreatedCount,
+		CommitRepositories:         user.CommitRepositoriesCount,
+		IssueRepositories:          user.IssueRepositoriesCount,
+		PullRequestRepositories:    user.PullRequestRepositoriesCount,
+		ReviewRepositories:         user.ReviewRepositoriesCount,
+		HasRestrictedContributions: user.HasRestrictedContributions}
+	for _, year := range user.History {
+		entry.History = append(entry.History, HistoryEntry{
+			Year:                 year.Year,
+			Contributions:        year.ContributionCount,
+			PublicContributions:  year.PublicContributionCount,
+			PrivateContributions: year.PrivateContributionCount,
+			Commits:              year.CommitsCount,
+			PullRequests:         year.PullRequestsCount,
+			Issues:               year.IssuesCount,
+			Reviews:              year.PullRequestReviewsCount})
+	}
+	return entry
+}
+
+// Leaderboard returns the leaderboard of the named metric.
+func (document Document) Leaderboard(metric string) (Leaderboard, bool) {
+	for _, leaderboard := range document.Leaderboards {
+		if leaderboard.Metric == metric {
+			return leaderboard, true
+		}
+	}
+	return Leaderboard{}, false
+}
+
+func JsonOutput(results github.GithubSearchResults, writer io.Writer, options top.Options) error {
+	document, err := NewDocument(results, options)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
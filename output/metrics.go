@@ -0,0 +1,113 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"most-active-github-users-counter/github"
+)
+
+// Metric is a named way of ranking users, selectable with --rank-by.
+type Metric struct {
+	Name        string
+	Description string
+	Selector    ContributionsSelector
+}
+
+var metrics = map[string]Metric{}
+
+// RegisterMetric adds a metric to the registry, replacing any metric of the same name.
+func RegisterMetric(metric Metric) {
+	metrics[metric.Name] = metric
+}
+
+func LookupMetric(name string) (Metric, error) {
+	metric, ok := metrics[name]
+	if !ok {
+		return Metric{}, fmt.Errorf("unknown metric %q, use one of: %s", name, strings.Join(metricNames(), ", "))
+	}
+	return metric, nil
+}
+
+// Metrics lists every registered metric by name.
+func Metrics() []Metric {
+	list := []Metric{}
+	for _, name := range metricNames() {
+		list = append(list, metrics[name])
+	}
+	return list
+}
+
+func metricNames() []string {
+	names := []string{}
+	for name := range metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type WeightedTerm struct {
+	Metric string
+	Weight float64
+}
+
+// Weighted builds a metric summing other registered metrics multiplied by their weights.
+func Weighted(name string, description string, terms ...WeightedTerm) (Metric, error) {
+	selectors := []ContributionsSelector{}
+	for _, term := range terms {
+		metric, err := LookupMetric(term.Metric)
+		if err != nil {
+			return Metric{}, err
+		}
+		selectors = append(selectors, metric.Selector)
+	}
+
+	return Metric{
+		Name:        name,
+		Description: description,
+		Selector: func(u github.User) int {
+			score := 0.0
+			for i, term := range terms {
+				score += term.Weight * float64(selectors[i](u))
+			}
+			return int(math.Round(score))
+		}}, nil
+}
+
+func init() {
+	for _, metric := range []Metric{
+		{"commits", "Commit contributions", func(u github.User) int { return u.CommitsCount }},
+		{"prs", "Pull requests opened", func(u github.User) int { return u.PullRequestsCount }},
+		{"reviews", "Pull request reviews", func(u github.User) int { return u.PullRequestReviewsCount }},
+		{"issues", "Issues opened", func(u github.User) int { return u.IssuesCount }},
+		{"repositories", "Repositories created", func(u github.User) int { return u.RepositoriesCreatedCount }},
+		{"commit_repositories", "Repositories committed to", func(u github.User) int { return u.CommitRepositoriesCount }},
+		{"issue_repositories", "Repositories with issues opened", func(u github.User) int { return u.IssueRepositoriesCount }},
+		{"pr_repositories", "Repositories with pull requests opened", func(u github.User) int { return u.PullRequestRepositoriesCount }},
+		{"review_repositories", "Repositories with pull requests reviewed", func(u github.User) int { return u.ReviewRepositoriesCount }},
+		{"public", "Public contributions", func(u github.User) int { return u.PublicContributionCount }},
+		{"private", "Private contributions", func(u github.User) int { return u.PrivateContributionCount }},
+		{"total", "Public and private contributions", func(u github.User) int { return u.ContributionCount }},
+		{"followers", "Followers", func(u github.User) int { return u.FollowerCount }},
+	} {
+		RegisterMetric(metric)
+	}
+
+	for _, composite := range []struct {
+		name        string
+		description string
+		terms       []WeightedTerm
+	}{
+		{"code", "Commits, pull requests and reviews", []WeightedTerm{{"commits", 1}, {"prs", 1}, {"reviews", 1}}},
+		{"weighted", "commits + prs*3 + reviews*2 + issues", []WeightedTerm{{"commits", 1}, {"prs", 3}, {"reviews", 2}, {"issues", 1}}},
+	} {
+		metric, err := Weighted(composite.name, composite.description, composite.terms...)
+		if err != nil {
+			panic(err)
+		}
+		RegisterMetric(metric)
+	}
+}
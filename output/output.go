@@ -5,45 +5,139 @@ import (
 	"fmt"
 	"io"
 	"math"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 
+	"most-active-github-users-counter/company"
 	"most-active-github-users-counter/github"
 	"most-active-github-users-counter/top"
 )
 
 type Format func(results github.GithubSearchResults, writer io.Writer, options top.Options) error
 
+// defaultMetric ranks plain and CSV output when no metric is selected, the
+// same as the main leaderboard of the YAML output.
+const defaultMetric = "commits"
+
+// defaultOrgAmount is the number of organizations ranked when none is selected.
+const defaultOrgAmount = 10
+
+// orgTopMembers is the number of top ranked members listed for each organization.
+const orgTopMembers = 5
+
+// MembersMetric ranks organizations by member count, see top.Options.OrgRankBy.
+const MembersMetric = "members"
+
+// orgMetric is the metric summed over members to rank organizations next to
+// a leaderboard of metric.
+func orgMetric(options top.Options, metric Metric) (Metric, error) {
+	switch options.OrgRankBy {
+	case "":
+		return metric, nil
+	case MembersMetric:
+		return Metric{MembersMetric, "Members", func(github.User) int { return 1 }}, nil
+	}
+	return Metric{}, fmt.Errorf("unknown organization ranking %q, use %s or leave it empty", options.OrgRankBy, MembersMetric)
+}
+
+func orgAmount(options top.Options) int {
+	if options.OrgAmount > 0 {
+		return options.OrgAmount
+	}
+	return defaultOrgAmount
+}
+
+// rankBy looks up the metric selected in options, or fallback if none is.
+func rankBy(options top.Options, fallback string) (Metric, error) {
+	if options.RankBy != "" {
+		return LookupMetric(options.RankBy)
+	}
+	return LookupMetric(fallback)
+}
+
+// rankOrder builds the tie-breaking and numbering of ranks selected in options.
+func rankOrder(options top.Options) (RankOrder, error) {
+	order := RankOrder{Competition: options.CompetitionRanks}
+	for _, name := range options.TieBreakers {
+		tieBreaker, err := LookupTieBreaker(name)
+		if err != nil {
+			return RankOrder{}, err
+		}
+		order.TieBreakers = append(order.TieBreakers, tieBreaker)
+	}
+	return order, nil
+}
+
 func PlainOutput(results github.GithubSearchResults, writer io.Writer, options top.Options) error {
-	users := GithubUserList(results.Users)
-	fmt.Fprintln(writer, "USERS\n--------")
-	for i, user := range users {
-		fmt.Fprintf(writer, "#%+v: %+v (%+v):%+v (%+v) %+v\n", i+1, user.Name, user.Login, user.ContributionCount, user.Company, strings.Join(user.Organizations, ","))
+	metric, err := rankBy(options, defaultMetric)
+	if err != nil {
+		return err
 	}
-	fmt.Fprintln(writer, "\nORGANIZATIONS\n--------")
-	for i, org := range users.TopOrgs(10) {
-		fmt.Fprintf(writer, "#%+v: %+v (%+v)\n", i+1, org.Name, org.MemberCount)
+	order, err := rankOrder(options)
+	if err != nil {
+		return err
+	}
+	orgsBy, err := orgMetric(options, metric)
+	if err != nil {
+		return err
+	}
+	ranking := Rank(results.Users, metric, options.Amount, order)
+	fmt.Fprintf(writer, "USERS (by %s)\n--------\n", metric.Name)
+	for _, user := range ranking {
+		fmt.Fprintf(writer, "#%+v: %+v (%+v):%+v (%+v) %+v\n", user.Rank, user.Name, user.Login, user.Value, user.Company, strings.Join(user.Organizations, ","))
+	}
+	fmt.Fprintf(writer, "\nORGANIZATIONS (by %s)\n--------\n", orgsBy.Name)
+	for _, org := range ranking.Users().TopOrgs(orgAmount(options), orgsBy, order, options.Companies) {
+		fmt.Fprintf(writer, "#%+v: %+v (%+v):%+v %+v\n", org.Rank, org.Name, org.MemberCount, org.Value, strings.Join(org.TopMembers, ","))
 	}
 	return nil
 }
 
 func CsvOutput(results github.GithubSearchResults, writer io.Writer, options top.Options) error {
-	users := GithubUserList(results.Users)
+	metric, err := rankBy(options, defaultMetric)
+	if err != nil {
+		return err
+	}
+	order, err := rankOrder(options)
+	if err != nil {
+		return err
+	}
+	ranking := Rank(results.Users, metric, options.Amount, order)
 	w := csv.NewWriter(writer)
-	if err := w.Write([]string{"rank", "name", "login", "contributions", "company", "organizations"}); err != nil {
+	// value is the metric users are ranked by, the columns before it are those of earlier versions
+	header := []string{"rank", "name", "login", "contributions", "company", "organizations", "value", "public_contributions", "private_contributions", "commits", "pull_requests", "issues", "reviews", "repositories_created", "commit_repositories", "issue_repositories", "pull_request_repositories", "review_repositories", "has_restricted_contributions"}
+	if options.History {
+		header = append(header, "history")
+	}
+	if err := w.Write(header); err != nil {
 		return err
 	}
-	for i, user := range users {
-		rank := strconv.Itoa(i + 1)
+	for _, user := range ranking {
+		rank := strconv.Itoa(user.Rank)
 		name := user.Name
 		login := user.Login
 		contribs := strconv.Itoa(user.ContributionCount)
 		orgs := strings.Join(user.Organizations, ",")
 		company := user.Company
-		if err := w.Write([]string{rank, name, login, contribs, company, orgs}); err != nil {
+		record := []string{rank, name, login, contribs, company, orgs,
+			strconv.Itoa(user.Value),
+			strconv.Itoa(user.PublicContributionCount),
+			strconv.Itoa(user.PrivateContributionCount),
+			strconv.Itoa(user.CommitsCount),
+			strconv.Itoa(user.PullRequestsCount),
+			strconv.Itoa(user.IssuesCount),
+			strconv.Itoa(user.PullRequestReviewsCount),
+			strconv.Itoa(user.RepositoriesCreatedCount),
+			strconv.Itoa(user.CommitRepositoriesCount),
+			strconv.Itoa(user.IssueRepositoriesCount),
+			strconv.Itoa(user.PullRequestRepositoriesCount),
+			strconv.Itoa(user.ReviewRepositoriesCount),
+			strconv.FormatBool(user.HasRestrictedContributions)}
+		if options.History {
+			record = append(record, formatHistory(user.History))
+		}
+		if err := w.Write(record); err != nil {
 			return err
 		}
 	}
@@ -51,11 +145,22 @@ func CsvOutput(results github.GithubSearchResults, writer io.Writer, options top
 	return nil
 }
 
+// formatHistory renders yearly totals as "year:contributions" pairs.
+func formatHistory(history []github.YearlyContributions) string {
+	years := []string{}
+	for _, year := range history {
+		years = append(years, fmt.Sprintf("%d:%d", year.Year, year.ContributionCount))
+	}
+	return strings.Join(years, ";")
+}
+
 type ContributionsSelector func(github.User) int
 
 type Filter func(github.User) bool
 
-func (users GithubUserList) TopBy(selector func(github.User) int, userFilter Filter, amount int) GithubUserList {
+// TopBy returns the top amount of users by selector. Users with equal values
+// are ordered by the tie-breakers and finally by login.
+func (users GithubUserList) TopBy(selector func(github.User) int, userFilter Filter, amount int, tieBreakers ...TieBreaker) GithubUserList {
 	cloned := clone(users)
 	if userFilter != nil {
 		var filtered []github.User
@@ -66,87 +171,10 @@ func (users GithubUserList) TopBy(selector func(github.User) int, userFilter Fil
 		}
 		cloned = filtered
 	}
-	sort.Slice(cloned, func(i, j int) bool {
-		return selector(cloned[i]) > selector(cloned[j])
-	})
+	cloned.sortBy(selector, RankOrder{TieBreakers: tieBreakers})
 	return trim(cloned, amount)
 }
 
-func YamlOutput(results github.GithubSearchResults, writer io.Writer, options top.Options) error {
-	users := GithubUserList(results.Users)
-
-	outputOrganizations := func(orgs Organizations) {
-		for i, org := range orgs {
-			fmt.Fprintf(
-				writer,
-				`
-  - rank: %+v
-    name: %+v
-    membercount: %+v
-`,
-				i+1,
-				strconv.QuoteToASCII(org.Name),
-				org.MemberCount)
-		}
-	}
-
-	outputUsers := func(user []github.User, cs ContributionsSelector) {
-		for i, u := range user {
-			contributionCount := cs(u)
-			fmt.Fprintf(
-				writer,
-				`
-  - rank: %+v
-    name: %+v
-    login: %+v
-    avatarUrl: %+v
-    contributions: %+v
-    company: %+v
-    organizations: %+v
-`,
-				i+1,
-				strconv.QuoteToASCII(u.Name),
-				strconv.QuoteToASCII(u.Login),
-				u.AvatarURL,
-				contributionCount,
-				strconv.QuoteToASCII(u.Company),
-				strconv.QuoteToASCII(strings.Join(u.Organizations, ",")))
-		}
-	}
-
-	topCommits := users.TopBy(func(u github.User) int { return u.CommitsCount }, nil, options.Amount)
-	fmt.Fprintln(writer, "users:")
-	outputUsers(topCommits, func(u github.User) int { return u.CommitsCount })
-
-	topPublic := users.TopBy(func(u github.User) int { return u.PublicContributionCount }, nil, options.Amount)
-	fmt.Fprintln(writer, "users_public_contributions:")
-	outputUsers(topPublic, func(u github.User) int { return u.PublicContributionCount })
-
-	topTotal := users.TopBy(func(u github.User) int { return u.ContributionCount }, nil, options.Amount)
-	fmt.Fprintln(writer, "\nprivate_users:")
-	outputUsers(topTotal, func(u github.User) int { return u.ContributionCount })
-
-	fmt.Fprintln(writer, "\norganizations:")
-	outputOrganizations(topCommits.TopOrgs(10))
-	fmt.Fprintln(writer, "\npublic_contributions_organizations:")
-	outputOrganizations(topPublic.TopOrgs(10))
-	fmt.Fprintln(writer, "\nprivate_organizations:")
-	outputOrganizations(topTotal.TopOrgs(10))
-
-	fmt.Fprintf(writer, "generated: %+v\n", time.Now().Format(time.RFC3339))
-	fmt.Fprintf(writer, "min_followers_required: %+v\n", results.MinimumFollowerCount)
-	fmt.Fprintf(writer, "total_user_count: %+v\n", results.TotalUserCount)
-
-	if options.PresetTitle != "" && options.PresetChecksum != "" {
-		fmt.Fprintf(writer, "title: %+v\n", options.PresetTitle)
-		fmt.Fprintf(writer, "definition_checksum: %+v\n", options.PresetChecksum)
-	}
-
-	return nil
-}
-
-var companyLogin = regexp.MustCompile(`^\@([a-zA-Z0-9]+)$`)
-
 func trim(users GithubUserList, numTop int) GithubUserList {
 	if numTop == 0 {
 		numTop = 256
@@ -179,54 +207,98 @@ func (slice GithubUserList) MinFollowers() int {
 }
 
 type Organization struct {
-	Name        string
-	MemberCount int
+	Rank int
+	// Value is the metric the organization was ranked by, summed over its members.
+	Value int
+	// Name keeps the casing of the organization's GitHub login when known.
+	Name                    string
+	MemberCount             int
+	CommitsCount            int
+	PublicContributionCount int
+	ContributionCount       int
+	// TopMembers are the logins of the organization's best ranked members.
+	TopMembers []string
+
+	members GithubUserList
 }
 
 type Organizations []Organization
 
-func (slice Organizations) Len() int {
-	return len(slice)
-}
-
-func (slice Organizations) Less(i, j int) bool {
-	return slice[i].MemberCount > slice[j].MemberCount
-}
-
-func (slice Organizations) Swap(i, j int) {
-	slice[i], slice[j] = slice[j], slice[i]
-}
-
-func (slice GithubUserList) TopOrgs(count int) Organizations {
-	orgsMap := make(map[string]int)
+// TopOrgs ranks the organizations of users by metric summed over their
+// members, then by member count and the tie-breakers of order, also summing
+// the contributions of their members. Users are expected in ranking
+// order, so the first members seen are the top ones. Company fields are read
+// by companies, or only for @mentions when it is nil.
+func (slice GithubUserList) TopOrgs(count int, metric Metric, order RankOrder, companies *company.Normaliser) Organizations {
+	if companies == nil {
+		companies = company.NewNormaliser(nil)
+	}
+	orgsMap := make(map[string]*Organization)
+	// names taken from a company field are replaced by the casing of an organization login
+	fromCompany := make(map[string]bool)
 	for _, user := range slice {
-		userOrgs := user.Organizations
-		orgMatches := companyLogin.FindStringSubmatch(strings.Trim(user.Company, " "))
-		if len(orgMatches) > 0 {
-			orgLogin := companyLogin.FindStringSubmatch(strings.Trim(user.Company, " "))[1]
-			if len(orgLogin) > 0 && !contains(userOrgs, orgLogin) {
+		userOrgs := append([]string{}, user.Organizations...)
+		companyOrgs := []string{}
+		for _, orgLogin := range companies.Organizations(user.Company) {
+			if !containsFold(userOrgs, orgLogin) {
+				companyOrgs = append(companyOrgs, orgLogin)
 				userOrgs = append(userOrgs, orgLogin)
 			}
 		}
 
 		for _, o := range userOrgs {
-			org := strings.ToLower(o)
-			orgsMap[org] = orgsMap[org] + 1
+			key := strings.ToLower(o)
+			org, ok := orgsMap[key]
+			if !ok {
+				org = &Organization{Name: o}
+				orgsMap[key] = org
+				fromCompany[key] = containsFold(companyOrgs, o)
+			} else if fromCompany[key] && !containsFold(companyOrgs, o) {
+				org.Name = o
+				fromCompany[key] = false
+			}
+			org.MemberCount++
+			org.Value += metric.Selector(user)
+			org.CommitsCount += user.CommitsCount
+			org.PublicContributionCount += user.PublicContributionCount
+			org.ContributionCount += user.ContributionCount
+			org.members = append(org.members, user)
+			if len(org.TopMembers) < orgTopMembers {
+				org.TopMembers = append(org.TopMembers, user.Login)
+			}
 		}
 	}
 
 	orgs := Organizations{}
 
-	for k, v := range orgsMap {
-		orgs = append(orgs, Organization{Name: k, MemberCount: v})
+	for _, org := range orgsMap {
+		orgs = append(orgs, *org)
 	}
-	sort.Sort(orgs)
+	less := order.lessOrgs()
+	sort.Slice(orgs, func(i, j int) bool {
+		return less(orgs[i], orgs[j])
+	})
 	if len(orgs) > count {
-		return orgs[:count]
+		orgs = orgs[:count]
+	}
+	ranks := order.ranks(len(orgs), func(i int) bool {
+		return orgs[i].Value == orgs[i-1].Value
+	})
+	for i := range orgs {
+		orgs[i].Rank = ranks[i]
 	}
 	return orgs
 }
 
+func containsFold(s []string, e string) bool {
+	for _, a := range s {
+		if strings.EqualFold(a, e) {
+			return true
+		}
+	}
+	return false
+}
+
 func contains(s []string, e string) bool {
 	for _, a := range s {
 		if a == e {
@@ -0,0 +1,160 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"most-active-github-users-counter/github"
+)
+
+type RankedUser struct {
+	github.User
+	Rank int
+	// Value is the user's score in the metric they were ranked by.
+	Value int
+}
+
+type Ranking []RankedUser
+
+// TieBreaker orders users, or organizations, whose ranking value is equal:
+// those with the higher Selector value, summed over the members of an
+// organization, come first. Without a Selector they are ordered by login or
+// name, which is unique and so always settles the order.
+type TieBreaker struct {
+	Name     string
+	Selector ContributionsSelector
+}
+
+var loginTieBreaker = TieBreaker{Name: "login"}
+
+// LookupTieBreaker finds a tie-breaker by name: "login", or any metric, which
+// puts the user or organization with the higher value first.
+func LookupTieBreaker(name string) (TieBreaker, error) {
+	if name == loginTieBreaker.Name {
+		return loginTieBreaker, nil
+	}
+	metric, err := LookupMetric(name)
+	if err != nil {
+		return TieBreaker{}, fmt.Errorf("unknown tie-breaker %q, use login or a metric: %w", name, err)
+	}
+	return TieBreaker{metric.Name, metric.Selector}, nil
+}
+
+// compareUsers is negative if a comes before b.
+func (tieBreaker TieBreaker) compareUsers(a github.User, b github.User) int {
+	if tieBreaker.Selector == nil {
+		return strings.Compare(strings.ToLower(a.Login), strings.ToLower(b.Login))
+	}
+	return tieBreaker.Selector(b) - tieBreaker.Selector(a)
+}
+
+// compareOrgs is negative if a comes before b.
+func (tieBreaker TieBreaker) compareOrgs(a Organization, b Organization) int {
+	if tieBreaker.Selector == nil {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}
+	return b.members.sum(tieBreaker.Selector) - a.members.sum(tieBreaker.Selector)
+}
+
+// RankOrder settles how ties are ordered and numbered.
+type RankOrder struct {
+	// TieBreakers are tried in order on users or organizations with equal
+	// values, falling back to their logins or names.
+	TieBreakers []TieBreaker
+	// Competition gives tied entries the same rank and skips the ranks after
+	// them ("1, 2, 2, 4"), rather than numbering every entry in turn.
+	Competition bool
+}
+
+func (order RankOrder) tieBreakers() []TieBreaker {
+	return append(append([]TieBreaker{}, order.TieBreakers...), loginTieBreaker)
+}
+
+// less orders users by selector, then by the tie-breakers.
+func (order RankOrder) less(selector ContributionsSelector) func(a github.User, b github.User) bool {
+	tieBreakers := order.tieBreakers()
+	return func(a github.User, b github.User) bool {
+		if selector(a) != selector(b) {
+			return selector(a) > selector(b)
+		}
+		for _, tieBreaker := range tieBreakers {
+			if result := tieBreaker.compareUsers(a, b); result != 0 {
+				return result < 0
+			}
+		}
+		return false
+	}
+}
+
+// lessOrgs orders organizations by value, then by member count and the
+// tie-breakers summed over their members.
+func (order RankOrder) lessOrgs() func(a Organization, b Organization) bool {
+	tieBreakers := order.tieBreakers()
+	return func(a Organization, b Organization) bool {
+		if a.Value != b.Value {
+			return a.Value > b.Value
+		}
+		if a.MemberCount != b.MemberCount {
+			return a.MemberCount > b.MemberCount
+		}
+		for _, tieBreaker := range tieBreakers {
+			if result := tieBreaker.compareOrgs(a, b); result != 0 {
+				return result < 0
+			}
+		}
+		return false
+	}
+}
+
+// ranks numbers count sorted entries, where tied reports whether entry i has
+// the same value as the one before it.
+func (order RankOrder) ranks(count int, tied func(i int) bool) []int {
+	ranks := make([]int, count)
+	for i := range ranks {
+		if order.Competition && i > 0 && tied(i) {
+			ranks[i] = ranks[i-1]
+		} else {
+			ranks[i] = i + 1
+		}
+	}
+	return ranks
+}
+
+// Rank is the ranking pipeline shared by all formats: it orders users by
+// metric, keeps the top amount of them and numbers them.
+func Rank(users []github.User, metric Metric, amount int, order RankOrder) Ranking {
+	top := GithubUserList(users).TopBy(metric.Selector, nil, amount, order.TieBreakers...)
+	ranks := order.ranks(len(top), func(i int) bool {
+		return metric.Selector(top[i]) == metric.Selector(top[i-1])
+	})
+
+	ranking := Ranking{}
+	for i, user := range top {
+		ranking = append(ranking, RankedUser{User: user, Rank: ranks[i], Value: metric.Selector(user)})
+	}
+	return ranking
+}
+
+func (ranking Ranking) Users() GithubUserList {
+	users := GithubUserList{}
+	for _, ranked := range ranking {
+		users = append(users, ranked.User)
+	}
+	return users
+}
+
+func (slice GithubUserList) sum(selector ContributionsSelector) int {
+	sum := 0
+	for _, user := range slice {
+		sum += selector(user)
+	}
+	return sum
+}
+
+func (slice GithubUserList) sortBy(selector ContributionsSelector, order RankOrder) {
+	less := order.less(selector)
+	sort.SliceStable(slice, func(i, j int) bool {
+		return less(slice[i], slice[j])
+	})
+}
@@ -0,0 +1,76 @@
+package output
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"most-active-github-users-counter/github"
+)
+
+func tiedUsers() []github.User {
+	return []github.User{
+		{Login: "eve", CommitsCount: 2, Organizations: []string{"acme"}},
+		{Login: "dan", CommitsCount: 5, PullRequestsCount: 3, Organizations: []string{"core", "zeta"}},
+		{Login: "cyd", CommitsCount: 5, PullRequestsCount: 3, Organizations: []string{"core"}},
+		{Login: "Bob", CommitsCount: 7, Organizations: []string{"beta", "alpha"}},
+		{Login: "ada", CommitsCount: 5, PullRequestsCount: 1, Organizations: []string{"acme"}},
+	}
+}
+
+func lookup(t *testing.T, name string) Metric {
+	metric, err := LookupMetric(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metric
+}
+
+func TestRankTies(t *testing.T) {
+	prs := TieBreaker{"prs", lookup(t, "prs").Selector}
+	for _, test := range []struct {
+		order RankOrder
+		want  []string
+	}{
+		{RankOrder{}, []string{"1 Bob", "2 ada", "3 cyd", "4 dan", "5 eve"}},
+		{RankOrder{Competition: true}, []string{"1 Bob", "2 ada", "2 cyd", "2 dan", "5 eve"}},
+		{RankOrder{TieBreakers: []TieBreaker{prs}}, []string{"1 Bob", "2 cyd", "3 dan", "4 ada", "5 eve"}},
+		{RankOrder{TieBreakers: []TieBreaker{prs}, Competition: true}, []string{"1 Bob", "2 cyd", "2 dan", "2 ada", "5 eve"}},
+	} {
+		users := tiedUsers()
+		for attempt := 0; attempt < 2; attempt++ {
+			got := []string{}
+			for _, user := range Rank(users, lookup(t, "commits"), 10, test.order) {
+				got = append(got, fmt.Sprintf("%d %s", user.Rank, user.Login))
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Rank(%+v) = %v, want %v", test.order, got, test.want)
+			}
+			// the order doesn't depend on the order of the input
+			for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+				users[i], users[j] = users[j], users[i]
+			}
+		}
+	}
+}
+
+func TestTopOrgsTies(t *testing.T) {
+	commits := lookup(t, "commits")
+	for _, test := range []struct {
+		order RankOrder
+		want  []string
+	}{
+		// acme ties alpha and beta on commits but has more members
+		{RankOrder{}, []string{"1 core 10", "2 acme 7", "3 alpha 7", "4 beta 7", "5 zeta 5"}},
+		{RankOrder{Competition: true}, []string{"1 core 10", "2 acme 7", "2 alpha 7", "2 beta 7", "5 zeta 5"}},
+	} {
+		users := Rank(tiedUsers(), commits, 10, test.order).Users()
+		got := []string{}
+		for _, org := range users.TopOrgs(10, commits, test.order, nil) {
+			got = append(got, fmt.Sprintf("%d %s %d", org.Rank, org.Name, org.Value))
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("TopOrgs(%+v) = %v, want %v", test.order, got, test.want)
+		}
+	}
+}
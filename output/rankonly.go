@@ -0,0 +1,91 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RankOnlyLocation holds the ranked logins of one location, as read by the
+// badge workers from rank_only.json. A login's rank is its index plus one.
+type RankOnlyLocation struct {
+	Title       string   `json:"title,omitempty"`
+	User        []string `json:"user"`
+	UserPublic  []string `json:"user_public"`
+	UserPrivate []string `json:"user_private"`
+	// organizations are lowercased, the way badge URLs name them
+	Org        []string `json:"org"`
+	OrgPublic  []string `json:"org_public"`
+	OrgPrivate []string `json:"org_private"`
+}
+
+// NewRankOnlyLocation takes the user and organization badges from the
+// commits, public and total leaderboards of document.
+func NewRankOnlyLocation(document Document) RankOnlyLocation {
+	users := func(metric string) []string {
+		logins := []string{}
+		leaderboard, _ := document.Leaderboard(metric)
+		for _, user := range leaderboard.Users {
+			logins = append(logins, user.Login)
+		}
+		return logins
+	}
+	orgs := func(metric string) []string {
+		names := []string{}
+		leaderboard, _ := document.Leaderboard(metric)
+		for _, org := range leaderboard.Organizations {
+			names = append(names, strings.ToLower(org.Name))
+		}
+		return names
+	}
+
+	return RankOnlyLocation{
+		Title:       document.Metadata.Title,
+		User:        users("commits"),
+		UserPublic:  users("public"),
+		UserPrivate: users("total"),
+		Org:         orgs("commits"),
+		OrgPublic:   orgs("public"),
+		OrgPrivate:  orgs("total")}
+}
+
+// ReadDocuments reads every <location>.json file in dir written by JsonOutput,
+// keyed by location. Other JSON files, such as a rank_only.json written to
+// the same dir, have no leaderboards and are skipped.
+func ReadDocuments(dir string) (map[string]Document, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	documents := map[string]Document{}
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		document := Document{}
+		if err := json.Unmarshal(content, &document); err != nil {
+			return nil, fmt.Errorf("error parsing results %s: %w", path, err)
+		}
+		if len(document.Leaderboards) == 0 {
+			log.Printf("skipping %s: no leaderboards", path)
+			continue
+		}
+		documents[strings.TrimSuffix(filepath.Base(path), ".json")] = document
+	}
+	return documents, nil
+}
+
+// RankOnly writes the rank_only.json used by the badge deployment from the
+// results of each location.
+func RankOnly(documents map[string]Document, writer io.Writer) error {
+	locations := map[string]RankOnlyLocation{}
+	for name, document := range documents {
+		locations[name] = NewRankOnlyLocation(document)
+	}
+	return json.NewEncoder(writer).Encode(locations)
+}
@@ -0,0 +1,45 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadDocumentsSkipsRankOnly(t *testing.T) {
+	dir := t.TempDir()
+	berlin := `{"leaderboards": [{"metric": "commits", "users": [{"rank": 1, "login": "ada"}], "organizations": [{"rank": 1, "name": "Acme"}]}]}`
+	if err := os.WriteFile(filepath.Join(dir, "berlin.json"), []byte(berlin), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rankOnly := func() []byte {
+		documents, err := ReadDocuments(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		locations := []string{}
+		for location := range documents {
+			locations = append(locations, location)
+		}
+		if want := []string{"berlin"}; !reflect.DeepEqual(locations, want) {
+			t.Errorf("locations = %v, want %v", locations, want)
+		}
+		buffer := bytes.Buffer{}
+		if err := RankOnly(documents, &buffer); err != nil {
+			t.Fatal(err)
+		}
+		return buffer.Bytes()
+	}
+
+	first := rankOnly()
+	// rank_only.json written next to the results is not read back as a location
+	if err := os.WriteFile(filepath.Join(dir, "rank_only.json"), first, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if second := rankOnly(); !bytes.Equal(first, second) {
+		t.Errorf("rerun wrote %s, want %s", second, first)
+	}
+}
@@ -0,0 +1,116 @@
+package output
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ScoreMetric is the name under which a parsed score formula is registered.
+const ScoreMetric = "score"
+
+// ParseScore parses a weighted formula such as "commits*1 + prs*3 + reviews*2"
+// over the registered metrics. A term without a weight counts once, and terms
+// or weights may be negative.
+func ParseScore(formula string) ([]WeightedTerm, error) {
+	terms := []WeightedTerm{}
+	for _, part := range splitTerms(strings.ReplaceAll(formula, " ", "")) {
+		term := WeightedTerm{Weight: 1}
+		for _, factor := range strings.Split(part, "*") {
+			for strings.HasPrefix(factor, "-") || strings.HasPrefix(factor, "+") {
+				if factor[0] == '-' {
+					term.Weight = -term.Weight
+				}
+				factor = factor[1:]
+			}
+			if weight, err := strconv.ParseFloat(factor, 64); err == nil {
+				if math.IsInf(weight, 0) || math.IsNaN(weight) {
+					return nil, fmt.Errorf("term %q of score %q has a weight that isn't a finite number", part, formula)
+				}
+				term.Weight *= weight
+			} else if _, err := LookupMetric(factor); err != nil {
+				return nil, err
+			} else if term.Metric != "" {
+				return nil, fmt.Errorf("term %q of score %q multiplies two metrics", part, formula)
+			} else {
+				term.Metric = factor
+			}
+		}
+		if term.Metric == "" {
+			return nil, fmt.Errorf("term %q of score %q has no metric", part, formula)
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("score %q has no terms", formula)
+	}
+	return terms, nil
+}
+
+// splitTerms splits expr before every + or - that adds a term, keeping the
+// sign with the term. Signs of a factor, after a *, or of an exponent, as in
+// 1e-3, stay within their term.
+func splitTerms(expr string) []string {
+	parts := []string{}
+	start := 0
+	for i := 1; i < len(expr); i++ {
+		if expr[i] != '+' && expr[i] != '-' {
+			continue
+		}
+		previous := expr[i-1]
+		exponent := (previous == 'e' || previous == 'E') && i >= 2 && (expr[i-2] >= '0' && expr[i-2] <= '9' || expr[i-2] == '.')
+		if previous == '*' || previous == '+' || previous == '-' || exponent {
+			continue
+		}
+		parts = append(parts, expr[start:i])
+		start = i
+	}
+	parts = append(parts, expr[start:])
+
+	terms := []string{}
+	for _, part := range parts {
+		if strings.Trim(part, "+-") != "" {
+			terms = append(terms, part)
+		}
+	}
+	return terms
+}
+
+// FormatScore writes terms back as a formula in a canonical form, with the
+// weights of each metric summed and the metrics sorted by name, so that
+// equivalent formulas are echoed and checksummed identically.
+func FormatScore(terms []WeightedTerm) string {
+	weights := map[string]float64{}
+	metrics := []string{}
+	for _, term := range terms {
+		if _, ok := weights[term.Metric]; !ok {
+			metrics = append(metrics, term.Metric)
+		}
+		weights[term.Metric] += term.Weight
+	}
+	sort.Strings(metrics)
+
+	parts := []string{}
+	for _, metric := range metrics {
+		parts = append(parts, fmt.Sprintf("%s*%s", metric, strconv.FormatFloat(weights[metric], 'f', -1, 64)))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// RegisterScore parses formula and registers it as the "score" metric,
+// returning its canonical form.
+func RegisterScore(formula string) (string, error) {
+	terms, err := ParseScore(formula)
+	if err != nil {
+		return "", err
+	}
+	canonical := FormatScore(terms)
+	metric, err := Weighted(ScoreMetric, canonical, terms...)
+	if err != nil {
+		return "", err
+	}
+	RegisterMetric(metric)
+	return canonical, nil
+}
@@ -0,0 +1,46 @@
+package output
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseScore(t *testing.T) {
+	for _, test := range []struct {
+		formula string
+		want    string
+		err     string
+	}{
+		{formula: "commits", want: "commits*1"},
+		{formula: "commits*1 + prs*3 + reviews*2", want: "commits*1 + prs*3 + reviews*2"},
+		{formula: "prs*3+commits", want: "commits*1 + prs*3"},
+		{formula: "-commits", want: "commits*-1"},
+		{formula: "commits*-1", want: "commits*-1"},
+		{formula: "commits - -prs*2", want: "commits*1 + prs*2"},
+		{formula: "commits - prs*2", want: "commits*1 + prs*-2"},
+		{formula: "1e-3*commits", want: "commits*0.001"},
+		{formula: "2E+1*prs - 1.5e2 * reviews", want: "prs*20 + reviews*-150"},
+		{formula: "commits + commits*2", want: "commits*3"},
+		{formula: "", err: "has no terms"},
+		{formula: " + ", err: "has no terms"},
+		{formula: "3", err: "has no metric"},
+		{formula: "commits*x", err: `unknown metric "x"`},
+		{formula: "prs*commits", err: "multiplies two metrics"},
+		{formula: "commits*inf", err: "isn't a finite number"},
+		{formula: "commits*-Inf", err: "isn't a finite number"},
+		{formula: "NaN*commits", err: "isn't a finite number"},
+	} {
+		terms, err := ParseScore(test.formula)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("ParseScore(%q) = %v, %v, want error containing %q", test.formula, terms, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseScore(%q) failed: %v", test.formula, err)
+		} else if got := FormatScore(terms); got != test.want {
+			t.Errorf("ParseScore(%q) = %q, want %q", test.formula, got, test.want)
+		}
+	}
+}
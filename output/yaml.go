@@ -0,0 +1,174 @@
+package output
+
+import (
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"most-active-github-users-counter/github"
+	"most-active-github-users-counter/top"
+)
+
+// yamlDocument is the YAML output read by the website's templates, which
+// rely on its key names.
+type yamlDocument struct {
+	Users                []yamlUser `yaml:"users"`
+	UsersPublic          []yamlUser `yaml:"users_public_contributions"`
+	PrivateUsers         []yamlUser `yaml:"private_users"`
+	Organizations        []yamlOrg  `yaml:"organizations"`
+	PublicOrganizations  []yamlOrg  `yaml:"public_contributions_organizations"`
+	PrivateOrganizations []yamlOrg  `yaml:"private_organizations"`
+	// Selected holds the users_by_<metric> and organizations_by_<metric>
+	// leaderboards of a metric chosen with --rank-by.
+	Selected map[string]interface{} `yaml:",inline"`
+
+	Generated            time.Time  `yaml:"generated"`
+	MinFollowersRequired int        `yaml:"min_followers_required"`
+	TotalUserCount       int        `yaml:"total_user_count"`
+	Query                string     `yaml:"query"`
+	ScoreFormula         string     `yaml:"score_formula,omitempty"`
+	ContributionsFrom    *time.Time `yaml:"contributions_from,omitempty"`
+	ContributionsTo      *time.Time `yaml:"contributions_to,omitempty"`
+	Title                string     `yaml:"title,omitempty"`
+	DefinitionChecksum   string     `yaml:"definition_checksum,omitempty"`
+}
+
+type yamlUser struct {
+	Rank      int    `yaml:"rank"`
+	Name      string `yaml:"name"`
+	Login     string `yaml:"login"`
+	AvatarURL string `yaml:"avatarUrl"`
+	// Contributions is the user's score in the leaderboard's metric.
+	Contributions              int           `yaml:"contributions"`
+	Company                    string        `yaml:"company"`
+	Organizations              []string      `yaml:"organizations"`
+	Commits                    int           `yaml:"commits"`
+	PullRequests               int           `yaml:"pull_requests"`
+	Issues                     int           `yaml:"issues"`
+	Reviews                    int           `yaml:"reviews"`
+	RepositoriesCreated        int           `yaml:"repositories_created"`
+	CommitRepositories         int           `yaml:"commit_repositories"`
+	IssueRepositories          int           `yaml:"issue_repositories"`
+	PullRequestRepositories    int           `yaml:"pull_request_repositories"`
+	ReviewRepositories         int           `yaml:"review_repositories"`
+	HasRestrictedContributions bool          `yaml:"has_restricted_contributions"`
+	History                    []yamlHistory `yaml:"history,omitempty"`
+}
+
+type yamlHistory struct {
+	Year                 int `yaml:"year"`
+	Contributions        int `yaml:"contributions"`
+	PublicContributions  int `yaml:"public_contributions"`
+	PrivateContributions int `yaml:"private_contributions"`
+	Commits              int `yaml:"commits"`
+	PullRequests         int `yaml:"pull_requests"`
+	Issues               int `yaml:"issues"`
+	Reviews              int `yaml:"reviews"`
+}
+
+type yamlOrg struct {
+	Rank                int      `yaml:"rank"`
+	Value               int      `yaml:"value"`
+	Name                string   `yaml:"name"`
+	MemberCount         int      `yaml:"membercount"`
+	Commits             int      `yaml:"commits"`
+	PublicContributions int      `yaml:"public_contributions"`
+	Contributions       int      `yaml:"contributions"`
+	TopMembers          []string `yaml:"top_members"`
+}
+
+func newYamlDocument(document Document) yamlDocument {
+	metadata := document.Metadata
+	out := yamlDocument{
+		Selected:             map[string]interface{}{},
+		Generated:            metadata.Generated.Truncate(time.Second),
+		MinFollowersRequired: metadata.MinFollowersRequired,
+		TotalUserCount:       metadata.TotalUserCount,
+		Query:                metadata.Query,
+		ScoreFormula:         metadata.ScoreFormula,
+		Title:                metadata.Title,
+		DefinitionChecksum:   metadata.DefinitionChecksum}
+	if metadata.ContributionsFrom != nil {
+		from := metadata.ContributionsFrom.Truncate(time.Second)
+		out.ContributionsFrom = &from
+	}
+	if metadata.ContributionsTo != nil {
+		to := metadata.ContributionsTo.Truncate(time.Second)
+		out.ContributionsTo = &to
+	}
+
+	for _, leaderboard := range document.Leaderboards {
+		users, orgs := newYamlUsers(leaderboard.Users), newYamlOrgs(leaderboard.Organizations)
+		switch leaderboard.Metric {
+		case "commits":
+			out.Users, out.Organizations = users, orgs
+		case "public":
+			out.UsersPublic, out.PublicOrganizations = users, orgs
+		case "total":
+			out.PrivateUsers, out.PrivateOrganizations = users, orgs
+		default:
+			out.Selected["users_by_"+leaderboard.Metric] = users
+			out.Selected["organizations_by_"+leaderboard.Metric] = orgs
+		}
+	}
+	return out
+}
+
+func newYamlUsers(entries []RankedUserEntry) []yamlUser {
+	users := []yamlUser{}
+	for _, u := range entries {
+		user := yamlUser{
+			Rank:                       u.Rank,
+			Name:                       u.Name,
+			Login:                      u.Login,
+			AvatarURL:                  u.AvatarURL,
+			Contributions:              u.Value,
+			Company:                    u.Company,
+			Organizations:              u.Organizations,
+			Commits:                    u.Commits,
+			PullRequests:               u.PullRequests,
+			Issues:                     u.Issues,
+			Reviews:                    u.Reviews,
+			RepositoriesCreated:        u.RepositoriesCreated,
+			CommitRepositories:         u.CommitRepositories,
+			IssueRepositories:          u.IssueRepositories,
+			PullRequestRepositories:    u.PullRequestRepositories,
+			ReviewRepositories:         u.ReviewRepositories,
+			HasRestrictedContributions: u.HasRestrictedContributions}
+		for _, year := range u.History {
+			user.History = append(user.History, yamlHistory(year))
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+func newYamlOrgs(entries []OrganizationEntry) []yamlOrg {
+	orgs := []yamlOrg{}
+	for _, org := range entries {
+		orgs = append(orgs, yamlOrg{
+			Rank:                org.Rank,
+			Value:               org.Value,
+			Name:                org.Name,
+			MemberCount:         org.MemberCount,
+			Commits:             org.Commits,
+			PublicContributions: org.PublicContributions,
+			Contributions:       org.Contributions,
+			TopMembers:          org.TopMembers})
+	}
+	return orgs
+}
+
+func YamlOutput(results github.GithubSearchResults, writer io.Writer, options top.Options) error {
+	document, err := NewDocument(results, options)
+	if err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(writer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(newYamlDocument(document)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
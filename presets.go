@@ -628,3 +628,14 @@ func PresetChecksum(name string) string {
 	io.WriteString(hash, fmt.Sprintf("%+v", Preset(name)))
 	return fmt.Sprintf("%x", hash.Sum(nil))
 }
+
+// DefinitionChecksum is the preset checksum with the score formula folded
+// in, so that pages ranked by a different formula are regenerated.
+func DefinitionChecksum(name string, score string) string {
+	if score == "" {
+		return PresetChecksum(name)
+	}
+	hash := sha256.New()
+	io.WriteString(hash, fmt.Sprintf("%+v\nscore: %s", Preset(name), score))
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
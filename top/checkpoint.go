@@ -0,0 +1,89 @@
+package top
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"most-active-github-users-counter/github"
+)
+
+// checkpointInterval is the number of pages between checkpoint writes.
+const checkpointInterval = 10
+
+// Checkpoint is the state of an interrupted search. The logins seen so far
+// are those of Users.
+type Checkpoint struct {
+	PresetChecksum string              `json:"presetChecksum"`
+	QueryString    string              `json:"query"`
+	MaxUsers       int                 `json:"maxUsers"`
+	Partition      string              `json:"partition"`
+	From           time.Time           `json:"from"`
+	To             time.Time           `json:"to"`
+	Cursor         github.SearchCursor `json:"cursor"`
+	TotalUserCount int                 `json:"totalUserCount"`
+	Users          []github.User       `json:"users"`
+}
+
+func newCheckpoint(options Options, query github.UserSearchQuery) Checkpoint {
+	return Checkpoint{
+		PresetChecksum: options.PresetChecksum,
+		QueryString:    query.Q,
+		MaxUsers:       query.MaxUsers,
+		Partition:      string(query.Partition),
+		From:           query.From,
+		To:             query.To,
+		Users:          []github.User{}}
+}
+
+// matches reports why a checkpoint can't be used to resume the expected search.
+func (checkpoint Checkpoint) matches(expected Checkpoint) error {
+	if checkpoint.PresetChecksum != expected.PresetChecksum {
+		return fmt.Errorf("preset checksum %q differs from %q", checkpoint.PresetChecksum, expected.PresetChecksum)
+	}
+	if checkpoint.QueryString != expected.QueryString || checkpoint.MaxUsers != expected.MaxUsers || checkpoint.Partition != expected.Partition {
+		return fmt.Errorf("search %q (consider %d, partition %q) differs from %q (consider %d, partition %q)",
+			checkpoint.QueryString, checkpoint.MaxUsers, checkpoint.Partition,
+			expected.QueryString, expected.MaxUsers, expected.Partition)
+	}
+	if !checkpoint.From.Equal(expected.From) || !checkpoint.To.Equal(expected.To) {
+		return fmt.Errorf("contribution window %v..%v differs from %v..%v", checkpoint.From, checkpoint.To, expected.From, expected.To)
+	}
+	return nil
+}
+
+func (checkpoint Checkpoint) seenLogins() map[string]bool {
+	seen := map[string]bool{}
+	for _, user := range checkpoint.Users {
+		seen[user.Login] = true
+	}
+	return seen
+}
+
+func loadCheckpoint(path string) (Checkpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	checkpoint := Checkpoint{}
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return Checkpoint{}, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	return checkpoint, nil
+}
+
+// save writes the checkpoint through a temporary file, so that a crash
+// while writing never leaves a truncated checkpoint behind.
+func (checkpoint Checkpoint) save(path string) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -1,34 +1,44 @@
 package top
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"os"
+	"time"
 
+	"most-active-github-users-counter/company"
 	"most-active-github-users-counter/github"
 	"most-active-github-users-counter/net"
 )
 
-func GithubTop(options Options) (github.GithubSearchResults, error) {
+const maxAttempts = 10
+
+func GithubTop(ctx context.Context, options Options) (github.GithubSearchResults, error) {
 	var token = options.Token
-	if token == "" {
+	if token == "" && options.Client == nil && !options.Offline {
 		return github.GithubSearchResults{}, errors.New("Missing GITHUB token")
 	}
 
-	query := "type:user"
-	for _, location := range options.Locations {
-		query = fmt.Sprintf("%s location:%s", query, location)
-	}
+	query := Query(options)
 
-	for _, location := range options.ExcludeLocations {
-		query = fmt.Sprintf("%s -location:%s", query, location)
+	var client = options.Client
+	limiter := net.NewRateLimiter(0)
+	if client == nil {
+		wrappers := append([]net.Wrapper{}, options.Wrappers...)
+		if token != "" {
+			wrappers = append(wrappers, net.TokenAuth(token))
+		}
+		wrappers = append(wrappers, net.RateLimitAware(limiter), net.Retry(maxAttempts, net.DefaultBackoff, net.DefaultRetryPolicy))
+		client = github.NewGithubClient(wrappers...)
 	}
-
-	var client = github.NewGithubClient(net.TokenAuth(token))
-	users, err := client.SearchUsers(github.UserSearchQuery{Q: query, Sort: "followers", Order: "desc", MaxUsers: options.ConsiderNum})
-	if err != nil {
-		return github.GithubSearchResults{}, err
+	users, err := search(ctx, client, github.UserSearchQuery{Q: query, Sort: "followers", Order: "desc", MaxUsers: options.ConsiderNum, Partition: options.Partition, From: options.From, To: options.To}, options)
+	if budget, ok := limiter.Budget(); ok {
+		log.Printf("API budget remaining: %d/%d (resets at %v)", budget.Remaining, budget.Limit, budget.Reset.Format(time.RFC3339))
 	}
 
+	// partial results are still ranked when the search was interrupted
 	filtered := []github.User{}
 	for _, u := range users.Users {
 		if options.Filter == nil || options.Filter(u) {
@@ -36,11 +46,112 @@ func GithubTop(options Options) (github.GithubSearchResults, error) {
 		}
 	}
 
+	if options.History && err == nil {
+		err = addHistory(ctx, client, filtered)
+	}
+
+	if options.Companies != nil && options.VerifyCompanies && err == nil {
+		companies := []string{}
+		for _, u := range filtered {
+			companies = append(companies, u.Company)
+		}
+		err = options.Companies.Verify(ctx, client.OrganizationExists, companies)
+	}
+
 	return github.GithubSearchResults{
 		Users:                filtered,
 		MinimumFollowerCount: github.MinFollowers(filtered),
 		TotalUserCount:       len(filtered),
-	}, nil
+	}, err
+}
+
+// Query is the user search query for the locations in options.
+func Query(options Options) string {
+	query := "type:user"
+	for _, location := range options.Locations {
+		query = fmt.Sprintf("%s location:%s", query, location)
+	}
+
+	for _, location := range options.ExcludeLocations {
+		query = fmt.Sprintf("%s -location:%s", query, location)
+	}
+	return query
+}
+
+// addHistory fills in the yearly contribution history of each user, which
+// costs two API requests per user.
+func addHistory(ctx context.Context, client github.Client, users []github.User) error {
+	for i := range users {
+		history, err := client.ContributionHistory(ctx, users[i].Login)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Printf("skipping contribution history: %v", err)
+			continue
+		}
+		users[i].History = history
+	}
+	return nil
+}
+
+// search streams the users of query, periodically saving progress to the
+// checkpoint file and resuming from it when requested.
+func search(ctx context.Context, client github.Client, query github.UserSearchQuery, options Options) (github.GithubSearchResults, error) {
+	checkpoint := newCheckpoint(options, query)
+	if options.Resume {
+		if options.CheckpointFile == "" {
+			return github.GithubSearchResults{}, errors.New("resuming requires a checkpoint file")
+		}
+		saved, err := loadCheckpoint(options.CheckpointFile)
+		if err != nil {
+			return github.GithubSearchResults{}, err
+		}
+		if err := saved.matches(checkpoint); err != nil {
+			return github.GithubSearchResults{}, fmt.Errorf("stale checkpoint %s: %w", options.CheckpointFile, err)
+		}
+		log.Printf("resuming from checkpoint with %d users", len(saved.Users))
+		checkpoint = saved
+		query.Resume = &saved.Cursor
+		query.SeenLogins = saved.seenLogins()
+	}
+
+	var err error
+	pages := 0
+	for page := range client.SearchUsersStream(ctx, query) {
+		if page.Err != nil {
+			err = page.Err
+			break
+		}
+		checkpoint.Users = append(checkpoint.Users, page.Users...)
+		checkpoint.Cursor = page.Cursor
+		checkpoint.TotalUserCount = page.Progress.QueryTotal
+
+		pages++
+		if options.CheckpointFile != "" && pages%checkpointInterval == 0 {
+			if err := checkpoint.save(options.CheckpointFile); err != nil {
+				log.Printf("error writing checkpoint: %v", err)
+			}
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	if options.CheckpointFile != "" {
+		if err != nil {
+			if saveErr := checkpoint.save(options.CheckpointFile); saveErr != nil {
+				log.Printf("error writing checkpoint: %v", saveErr)
+			}
+		} else if removeErr := os.Remove(options.CheckpointFile); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.Printf("error removing checkpoint: %v", removeErr)
+		}
+	}
+
+	return github.GithubSearchResults{
+		Users:                checkpoint.Users,
+		MinimumFollowerCount: checkpoint.Cursor.MinFollowerCount,
+		TotalUserCount:       checkpoint.TotalUserCount}, err
 }
 
 type Options struct {
@@ -52,4 +163,38 @@ type Options struct {
 	PresetTitle      string
 	PresetChecksum   string
 	Filter           func(github.User) bool
+	Partition        github.PartitionStrategy
+	// From and To set the contribution window, the last year by default.
+	From time.Time
+	To   time.Time
+	// Wrappers are applied closest to the HTTP client, e.g. net.Record or net.Replay.
+	Wrappers []net.Wrapper
+	// Offline allows running without a Token, when Wrappers serve every
+	// response, e.g. with net.Replay.
+	Offline bool
+	// Client replaces the GitHub API client built from Token and Wrappers, e.g. with a github.FakeClient.
+	Client github.Client
+	// CheckpointFile, when set, periodically receives the search state so
+	// that an interrupted run can continue from it with Resume.
+	CheckpointFile string
+	Resume         bool
+	// History fetches the yearly contribution history of every user.
+	History bool
+	// RankBy names the output metric users are ranked by, see output.Metrics.
+	RankBy string
+	// Score is the canonical weighted formula registered as the "score" metric, if any.
+	Score string
+	// TieBreakers name the metrics, or "login", that order users with equal
+	// values; CompetitionRanks gives them equal ranks instead.
+	TieBreakers      []string
+	CompetitionRanks bool
+	// OrgAmount is the number of organizations ranked, 10 by default.
+	OrgAmount int
+	// OrgRankBy is "members" to rank organizations by member count, rather
+	// than by the sum of each leaderboard's metric over their members.
+	OrgRankBy string
+	// Companies reads the employers in company fields, @mentions only when nil.
+	// With VerifyCompanies the organizations it finds are checked to exist.
+	Companies       *company.Normaliser
+	VerifyCompanies bool
 }
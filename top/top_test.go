@@ -0,0 +1,55 @@
+package top
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"most-active-github-users-counter/github"
+)
+
+func TestGithubTop(t *testing.T) {
+	client := github.NewFakeClient(
+		github.User{Login: "ada", FollowerCount: 50, Company: "@acme"},
+		github.User{Login: "bob", FollowerCount: 40, Company: "@acme"},
+		github.User{Login: "cyd", FollowerCount: 30, Company: "@other"},
+		github.User{Login: "dee", FollowerCount: 20, Company: "@acme"},
+		github.User{Login: "eve", FollowerCount: 10, Company: "@acme"},
+		github.User{Login: "fay", FollowerCount: 90, Company: "@acme"},
+	)
+	elsewhere := map[string]bool{"fay": true}
+	client.Match = func(query github.UserSearchQuery, user github.User) bool {
+		return strings.Contains(query.Q, "location:here") && !elsewhere[user.Login]
+	}
+
+	results, err := GithubTop(context.Background(), Options{
+		Locations:   []string{"here"},
+		ConsiderNum: 4,
+		Filter:      func(user github.User) bool { return user.Company == "@acme" },
+		Client:      client})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logins := []string{}
+	for _, user := range results.Users {
+		logins = append(logins, user.Login)
+	}
+	// eve is beyond the 4 users considered, cyd is filtered out
+	if want := []string{"ada", "bob", "dee"}; !reflect.DeepEqual(logins, want) {
+		t.Errorf("users = %v, want %v", logins, want)
+	}
+	if results.MinimumFollowerCount != 20 {
+		t.Errorf("MinimumFollowerCount = %d, want 20", results.MinimumFollowerCount)
+	}
+	if results.TotalUserCount != 3 {
+		t.Errorf("TotalUserCount = %d, want 3", results.TotalUserCount)
+	}
+}
+
+func TestGithubTopWithoutToken(t *testing.T) {
+	if _, err := GithubTop(context.Background(), Options{}); err == nil {
+		t.Error("GithubTop without a token or client succeeded")
+	}
+}